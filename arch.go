@@ -0,0 +1,57 @@
+package gomine
+
+import "runtime"
+
+// javaArchs maps GOARCH values to values of Java's os.arch property as
+// reported by OpenJDK on Linux. Rules in version info are matched against
+// these.
+var javaArchs = map[string]string{
+	"386":      "x86",
+	"amd64":    "amd64",
+	"arm":      "arm",
+	"arm64":    "aarch64",
+	"loong64":  "loongarch64",
+	"mips":     "mips",
+	"mipsle":   "mipsel",
+	"mips64":   "mips64",
+	"mips64le": "mips64el",
+	"ppc64":    "ppc64",
+	"ppc64le":  "ppc64le",
+	"riscv64":  "riscv64",
+	"s390x":    "s390x",
+}
+
+// archs32 lists 32-bit GOARCH values.
+var archs32 = map[string]bool{
+	"386":    true,
+	"arm":    true,
+	"mips":   true,
+	"mipsle": true,
+}
+
+// javaArch returns value of os.arch property for GOARCH value. Unknown
+// values are returned as is.
+func javaArch(goarch string) string {
+	if arch, ok := javaArchs[goarch]; ok {
+		return arch
+	}
+	return goarch
+}
+
+// archBits returns value used in place of ${arch} in natives classifiers:
+// "32" or "64".
+func archBits(goarch string) string {
+	if archs32[goarch] {
+		return "32"
+	}
+	return "64"
+}
+
+// arch returns architecture (as GOARCH value) game should be installed and
+// run for. Safe to call on nil Profile.
+func (p *Profile) arch() string {
+	if p == nil || p.Arch == "" {
+		return runtime.GOARCH
+	}
+	return p.Arch
+}
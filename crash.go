@@ -0,0 +1,159 @@
+package gomine
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CrashReport is returned by GameProcess.Wait if game exits with non-zero
+// status.
+type CrashReport struct {
+	ExitCode int
+
+	// Err is the error returned when waiting for process, usually
+	// *exec.ExitError.
+	Err error
+
+	// Path is the crash report written by game. It is empty if there is
+	// none, other fields describing report are empty then too.
+	Path string
+
+	// Description is the crash description ("Unexpected error",
+	// "Initializing game", etc.).
+	Description string
+
+	// Exception is the first line of stack trace: exception class and
+	// message.
+	Exception  string
+	StackTrace string
+
+	// Mods are the lines of mods list section added by Forge, Fabric or
+	// Quilt.
+	Mods []string
+
+	// JVMErrorLogs are the hs_err_pid*.log files written by JVM if it
+	// crashed itself.
+	JVMErrorLogs []string
+}
+
+func (cr *CrashReport) Error() string {
+	msg := "game crashed"
+	if cr.Description != "" {
+		msg += ": " + cr.Description
+	}
+	if cr.Exception != "" {
+		msg += ": " + cr.Exception
+	}
+	if cr.Path == "" {
+		msg += " (exit code " + strconv.Itoa(cr.ExitCode) + ")"
+	}
+	if len(cr.JVMErrorLogs) != 0 {
+		msg += " (JVM crashed, see " + cr.JVMErrorLogs[0] + ")"
+	}
+	return msg
+}
+
+// modListKeys are System Details entries crash report lists mods under.
+var modListKeys = []string{
+	"\tMod List:",    // Forge
+	"\tFabric Mods:", // Fabric
+	"\tQuilt Mods:",  // Quilt
+}
+
+// collectCrashReport builds CrashReport from files written in gameDir since
+// startTime.
+func collectCrashReport(gameDir string, startTime time.Time, exitCode int, err error) *CrashReport {
+	cr := &CrashReport{
+		ExitCode: exitCode,
+		Err:      err,
+	}
+	// File systems may have mtime with seconds precision.
+	since := startTime.Truncate(time.Second)
+
+	reports, _ := ioutil.ReadDir(filepath.Join(gameDir, "crash-reports"))
+	var newest os.FileInfo
+	for _, info := range reports {
+		if info.IsDir() || info.ModTime().Before(since) {
+			continue
+		}
+		if newest == nil || info.ModTime().After(newest.ModTime()) {
+			newest = info
+		}
+	}
+	if newest != nil {
+		cr.Path = filepath.Join(gameDir, "crash-reports", newest.Name())
+		if blob, err := ioutil.ReadFile(cr.Path); err == nil {
+			cr.parse(string(blob))
+		}
+	}
+
+	jvmLogs, _ := filepath.Glob(filepath.Join(gameDir, "hs_err_pid*.log"))
+	for _, path := range jvmLogs {
+		if info, err := os.Stat(path); err == nil && !info.ModTime().Before(since) {
+			cr.JVMErrorLogs = append(cr.JVMErrorLogs, path)
+		}
+	}
+	return cr
+}
+
+// parse extracts description, stack trace and mods list from crash report
+// text:
+//
+//	---- Minecraft Crash Report ----
+//	// Witty comment
+//
+//	Time: 2023-06-12 13:25:51
+//	Description: Unexpected error
+//
+//	java.lang.NullPointerException: Cannot invoke ...
+//		at net.minecraft.client.Minecraft.run(Minecraft.java:718)
+//	...
+//	-- System Details --
+//	Details:
+//		Fabric Mods:
+//			fabric-api: Fabric API 0.83.0+1.20.1
+func (cr *CrashReport) parse(text string) {
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if strings.HasPrefix(line, "Description: ") && cr.Description == "" {
+			cr.Description = strings.TrimPrefix(line, "Description: ")
+
+			// Stack trace follows after empty line.
+			j := i + 1
+			for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+				j++
+			}
+			start := j
+			for j < len(lines) && strings.TrimSpace(lines[j]) != "" {
+				j++
+			}
+			if start < j {
+				cr.Exception = lines[start]
+				cr.StackTrace = strings.Join(lines[start:j], "\n")
+			}
+			i = j
+			continue
+		}
+
+		for _, key := range modListKeys {
+			if !strings.HasPrefix(line, key) {
+				continue
+			}
+			// Mods are listed on following lines with deeper indentation,
+			// nested mods are skipped.
+			for i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t\t") {
+				i++
+				if !strings.HasPrefix(lines[i], "\t\t\t") {
+					cr.Mods = append(cr.Mods, strings.TrimSpace(lines[i]))
+				}
+			}
+			break
+		}
+	}
+}
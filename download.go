@@ -2,14 +2,13 @@ package gomine
 
 import (
 	"archive/zip"
-	"crypto/sha1"
-	"encoding/hex"
+	"context"
 	"encoding/json"
 	"io"
 	"io/ioutil"
 	"log"
-	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -18,31 +17,84 @@ import (
 )
 
 func (l *Lib) ShouldUse() bool {
-	return EvaluateRules(l.Rules, nil)
+	return EvaluateRules(l.Rules, nil, nil)
 }
 
+// Native returns natives artifact of library with "natives" block for current
+// OS. nil is returned if library has no such artifact.
+//
+// Natives libraries used since 1.19 (like org.lwjgl:lwjgl:3.3.1:natives-linux)
+// have no separate natives artifact, their main artifact is used instead.
 func (l *Lib) Native() *Artifact {
-	if runtime.GOOS == "linux" {
-		return l.Downloads.NativesLinux
+	return l.native(nil)
+}
+
+// native is Native for platform prof is prepared for.
+func (l *Lib) native(prof *Profile) *Artifact {
+	classifier := l.nativeClassifier(prof)
+	if classifier == "" {
+		return nil
+	}
+	return l.Downloads.Classifiers[classifier]
+}
+
+// nativeClassifier returns classifier of natives artifact for current OS with
+// ${arch} substituted or empty string if library has no natives for current
+// OS.
+func (l *Lib) nativeClassifier(prof *Profile) string {
+	// If classifier contains ${arch} is should be replaced with "32" or "64".
+	nativeStr := l.NativeSuffixes[ruleOSName(runtime.GOOS)]
+	return strings.Replace(nativeStr, "${arch}", archBits(prof.arch()), -1)
+}
+
+// nativesPlatform returns OS and architecture (as GOOS and GOARCH values)
+// natives library is built for, ok is false if library is not a natives
+// library as used since 1.19. goarch is empty if classifier doesn't specify
+// architecture, such libraries are built for x86-64.
+func (l *Lib) nativesPlatform() (goos, goarch string, ok bool) {
+	coord, err := l.Coord()
+	if err != nil || !strings.HasPrefix(coord.Classifier, "natives-") {
+		return "", "", false
 	}
-	if runtime.GOOS == "darwin" {
-		return l.Downloads.NativesMacOS
+
+	// natives-<os>[-<arch>], e.g. natives-macos-arm64.
+	parts := strings.SplitN(strings.TrimPrefix(coord.Classifier, "natives-"), "-", 2)
+	switch parts[0] {
+	case "osx", "macos":
+		goos = "darwin"
+	default:
+		goos = parts[0]
 	}
-	if runtime.GOOS == "windows" {
-		return l.Downloads.NativesWin
+	if len(parts) == 1 {
+		return goos, "", true
 	}
-	return nil
+	switch parts[1] {
+	case "x86":
+		goarch = "386"
+	case "x64", "x86_64":
+		goarch = "amd64"
+	case "arm32":
+		goarch = "arm"
+	case "aarch64", "aarch_64":
+		goarch = "arm64"
+	default:
+		goarch = parts[1]
+	}
+	return goos, goarch, true
 }
 
 // SavePath returns FS path where library should be stored when downloaded (path is relative to libraries directory root).
 func (l *Lib) SavePath() (string, error) {
-	// libraries/<package>/<name>/<version>/<name>-<version>.jar
-	pkg, name, version, err := l.SplitName()
-	if pkg == "" {
+	if l.Downloads.MainJar != nil && l.Downloads.MainJar.Path != "" {
+		return filepath.FromSlash(l.Downloads.MainJar.Path), nil
+	}
+
+	// libraries/<package>/<name>/<version>/<name>-<version>[-<classifier>].<ext>
+	coord, err := l.Coord()
+	if err != nil {
 		return "", err
 	}
-	pkgPath := strings.Replace(pkg, ".", string(os.PathSeparator), -1)
-	return filepath.Join(pkgPath, name, version, name+"-"+version+".jar"), nil
+	return coord.Path(), nil
 }
 
 // NativeSavePath returns FS path where library's "native" component should be stored when downloaded (path is
@@ -50,49 +102,60 @@ func (l *Lib) SavePath() (string, error) {
 //
 // If library have no native component - empty string is returned.
 func (l *Lib) NativeSavePath() (string, error) {
-	native := l.Native()
-	if native == nil {
+	return l.nativeSavePath(nil)
+}
+
+// nativeSavePath is NativeSavePath for platform prof is prepared for.
+func (l *Lib) nativeSavePath(prof *Profile) (string, error) {
+	if l.native(prof) == nil {
 		return "", nil
 	}
 
 	// libraries/<package>/<name>/<version>/<name>-<version>-<native_string>.jar
-	// If <native_string> contains ${arch} is should be replaced with "32" or "64".
-
-	var nativeStr string
-	if runtime.GOOS == "linux" {
-		nativeStr = l.NativeSuffixes.Linux
-	}
-	if runtime.GOOS == "darwin" {
-		nativeStr = l.NativeSuffixes.MacOS
-	}
-	if runtime.GOOS == "windows" {
-		nativeStr = l.NativeSuffixes.Windows
+	coord, err := l.Coord()
+	if err != nil {
+		return "", err
 	}
-	if runtime.GOARCH == "386" { // TODO: check for other 32-bit archs
-		nativeStr = strings.Replace(nativeStr, "${arch}", "32", -1)
-	} else {
-		nativeStr = strings.Replace(nativeStr, "${arch}", "64", -1)
+	return coord.WithClassifier(l.nativeClassifier(prof)).Path(), nil
+}
+
+// nativesJarPath returns path (relative to libraries directory root) of jar
+// natives should be extracted from: natives artifact for libraries with
+// "natives" block or library itself for natives libraries used since 1.19.
+//
+// If library have no natives - empty string is returned.
+func (l *Lib) nativesJarPath(prof *Profile) (string, error) {
+	if _, _, ok := l.nativesPlatform(); ok {
+		return l.SavePath()
 	}
+	return l.nativeSavePath(prof)
+}
 
-	pkg, name, version, err := l.SplitName()
+// Coord parses library name as Maven coordinate.
+func (l *Lib) Coord() (Coord, error) {
+	coord, err := ParseCoord(l.Name)
 	if err != nil {
-		return "", err
+		return Coord{}, errors.New("malformed library name: " + l.Name)
 	}
-	pkgPath := strings.Replace(pkg, ".", string(os.PathSeparator), -1)
-	return filepath.Join(pkgPath, name, version, name+"-"+version+"-"+nativeStr+".jar"), nil
+	return coord, nil
 }
 
 func (l *Lib) SplitName() (pkg, name, version string, err error) {
-	splitten := strings.Split(l.Name, ":")
-	if len(splitten) != 3 {
-		return "", "", "", errors.New("malformed library name: " + l.Name)
+	coord, err := l.Coord()
+	if err != nil {
+		return "", "", "", err
 	}
-	// <package>:<name>:<version>
-	return splitten[0], splitten[1], splitten[2], nil
+	// <package>:<name>:<version>[:<classifier>][@<ext>]
+	return coord.Group, coord.Artifact, coord.Version, nil
 }
 
 func (l *Lib) ExtractNative(libDir, nativeDir string) error {
-	path, err := l.NativeSavePath()
+	return l.extractNative(libDir, nativeDir, nil)
+}
+
+// extractNative is ExtractNative for platform prof is prepared for.
+func (l *Lib) extractNative(libDir, nativeDir string, prof *Profile) error {
+	path, err := l.nativesJarPath(prof)
 	if err != nil {
 		return err
 	}
@@ -111,21 +174,12 @@ func (l *Lib) ExtractNative(libDir, nativeDir string) error {
 	defer r.Close()
 
 	for _, file := range r.File {
-		skip := false
-		for _, exclude := range l.ExtractRules.Exclude {
-			if strings.HasPrefix(file.Name, exclude) {
-				skip = true
-				break
-			}
-		}
-		if skip {
+		target := l.nativeTarget(file)
+		if target == "" {
 			continue
 		}
-
-		if file.FileInfo().IsDir() {
-			continue
-		}
-		if _, err := os.Stat(filepath.Join(nativeDir, file.Name)); err == nil {
+		targetPath := filepath.Join(nativeDir, target)
+		if _, err := os.Stat(targetPath); err == nil {
 			continue
 		}
 		log.Println("Extracting", file.Name, "from", nativePath+"...")
@@ -135,11 +189,11 @@ func (l *Lib) ExtractNative(libDir, nativeDir string) error {
 			return errors.Wrapf(err, "failed to extract %s", file.Name)
 		}
 
-		if err := os.MkdirAll(filepath.Dir(filepath.Join(nativeDir, file.Name)), os.ModePerm); err != nil {
+		if err := os.MkdirAll(filepath.Dir(targetPath), os.ModePerm); err != nil {
 			return errors.Wrap(err, "failed to create target dir")
 		}
 
-		out, err := os.Create(filepath.Join(nativeDir, file.Name))
+		out, err := os.Create(targetPath)
 		if err != nil {
 			return errors.Wrapf(err, "failed to open %s for writting", file.Name)
 		}
@@ -154,159 +208,404 @@ func (l *Lib) ExtractNative(libDir, nativeDir string) error {
 	return nil
 }
 
-func (v *Version) DownloadLibraries(libDir string) error {
-	// TODO: download progress callback
-	for _, lib := range v.Libraries {
-		if !lib.ShouldUse() {
-			continue
+// nativeTarget returns path (relative to natives directory) file from natives
+// jar should be extracted to or empty string if it should not be extracted.
+func (l *Lib) nativeTarget(file *zip.File) string {
+	if file.FileInfo().IsDir() || l.extractExcluded(file.Name) {
+		return ""
+	}
+	if _, _, ok := l.nativesPlatform(); !ok {
+		return filepath.FromSlash(file.Name)
+	}
+
+	// Natives libraries used since 1.19 have no extract rules and keep
+	// shared libraries in <os>/<arch>/<package>/ along with hashes and
+	// license files. Put just shared libraries directly into natives
+	// directory so they can be found using java.library.path.
+	switch path.Ext(file.Name) {
+	case ".so", ".dylib", ".jnilib", ".dll":
+		return path.Base(file.Name)
+	}
+	return ""
+}
+
+// mavenArtifact returns artifact for library that has no downloads block and
+// should be fetched from Maven repository instead. nil is returned for
+// libraries with downloads block or natives.
+//
+// savePath is the path returned by SavePath.
+func (l *Lib) mavenArtifact(savePath string) *Artifact {
+	if l.Downloads.MainJar != nil || len(l.NativeSuffixes) != 0 {
+		return nil
+	}
+
+	repo := l.URL
+	if repo == "" {
+		repo = DefaultLibrariesURL
+	}
+	return &Artifact{
+		URL:  strings.TrimSuffix(repo, "/") + "/" + filepath.ToSlash(savePath),
+		SHA1: l.SHA1,
+		Size: l.Size,
+	}
+}
+
+func (l *Lib) extractExcluded(name string) bool {
+	for _, exclude := range l.ExtractRules.Exclude {
+		if strings.HasPrefix(name, exclude) {
+			return true
 		}
+	}
+	return false
+}
+
+// DownloadLibraries downloads libraries and natives used on current platform
+// using default Downloader. Use Downloader.DownloadLibraries to configure
+// download.
+func (v *Version) DownloadLibraries(libDir string) error {
+	return (&Downloader{}).DownloadLibraries(context.Background(), v, libDir)
+}
+
+// DownloadLibraries downloads libraries and natives of v used on current
+// platform to libDir.
+func (d *Downloader) DownloadLibraries(ctx context.Context, v *Version, libDir string) error {
+	jobs, err := v.libraryJobs(libDir, nil)
+	if err != nil {
+		return err
+	}
+	return d.run(ctx, jobs)
+}
+
+// libraryJobs returns libraries and natives used on platform prof is prepared
+// for (nil means current one).
+func (v *Version) libraryJobs(libDir string, prof *Profile) ([]downloadJob, error) {
+	jobs := make([]downloadJob, 0, len(v.Libraries))
+	for _, lib := range v.activeLibraries(prof) {
 
 		// Download path is same as save path.
 		// https://libraries.minecraft.net/<package>/<name>/<version>/<name>-<version>.jar
 		path, err := lib.SavePath()
 		if err != nil {
-			return errors.Wrapf(err, "failed to get save path for %s", lib.Name)
+			return nil, errors.Wrapf(err, "failed to get save path for %s", lib.Name)
 		}
-		if path != "" && lib.Downloads.MainJar != nil {
-			if err := lib.Downloads.MainJar.Download(filepath.Join(libDir, path)); err != nil {
-				return errors.Wrapf(err, "failed to download %s", lib.Name)
-			}
+		artifact := lib.Downloads.MainJar
+		if artifact == nil {
+			artifact = lib.mavenArtifact(path)
+		}
+		// Artifacts without URL are produced locally by installers.
+		if path != "" && artifact != nil && artifact.URL != "" {
+			jobs = append(jobs, artifact.job(lib.Name, filepath.Join(libDir, path)))
 		}
 
-		nativePath, err := lib.NativeSavePath()
+		nativePath, err := lib.nativeSavePath(prof)
 		if err != nil {
-			return errors.Wrapf(err, "failed to get native save path for %s", lib.Name)
+			return nil, errors.Wrapf(err, "failed to get native save path for %s", lib.Name)
 		}
 		if nativePath != "" {
-			if err := lib.Native().Download(filepath.Join(libDir, nativePath)); err != nil {
-				return errors.Wrapf(err, "failed to download natives for %s", lib.Name)
-			}
+			jobs = append(jobs, lib.native(prof).job("natives for "+lib.Name, filepath.Join(libDir, nativePath)))
 		}
 	}
-	return nil
+	return jobs, nil
 }
 
+// ExtractNatives extracts natives from libraries used on current platform to
+// nativeDir.
 func (v *Version) ExtractNatives(libDir, nativeDir string) error {
-	for _, lib := range v.Libraries {
-		if !lib.ShouldUse() || lib.Native() == nil {
+	return v.extractNatives(libDir, nativeDir, nil)
+}
+
+// extractNatives is ExtractNatives for platform prof is prepared for.
+func (v *Version) extractNatives(libDir, nativeDir string, prof *Profile) error {
+	for _, lib := range v.activeLibraries(prof) {
+		path, err := lib.nativesJarPath(prof)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get natives path for %s", lib.Name)
+		}
+		if path == "" {
 			continue
 		}
-		if err := lib.ExtractNative(libDir, nativeDir); err != nil {
+		if err := lib.extractNative(libDir, nativeDir, prof); err != nil {
 			return errors.Wrapf(err, "failed to extract natives for %s", lib.Name)
 		}
 	}
 	return nil
 }
 
-func (v *Version) DownloadClient(versionsDir string) error {
-	jarPath := filepath.Join(versionsDir, v.ID, v.ID+".jar")
+// activeLibraries returns libraries that should be used on platform prof is
+// prepared for (nil means current one): ones allowed by rules and, for natives
+// libraries used since 1.19, built for its OS and architecture.
+//
+// Natives libraries without architecture in classifier (like natives-linux)
+// are used on other architectures too unless there is one built specifically
+// for it (like natives-linux-arm64).
+func (v *Version) activeLibraries(prof *Profile) []Lib {
+	goos, goarch := runtime.GOOS, prof.arch()
+
+	// natives library name without classifier => there is natives
+	// library for current architecture.
+	archSpecific := make(map[string]bool)
+	baseName := func(lib Lib) string {
+		coord, _ := lib.Coord()
+		return coord.WithClassifier("").String()
+	}
+	allowed := make([]Lib, 0, len(v.Libraries))
+	for _, lib := range v.Libraries {
+		if !EvaluateRules(lib.Rules, prof, nil) {
+			continue
+		}
+		allowed = append(allowed, lib)
 
-	if err := os.MkdirAll(filepath.Dir(jarPath), os.ModePerm); err != nil {
-		return errors.Wrap(err, "failed to create version directory")
+		if libOS, libArch, ok := lib.nativesPlatform(); ok && libOS == goos && libArch == goarch {
+			archSpecific[baseName(lib)] = true
+		}
+	}
+
+	libs := allowed[:0]
+	for _, lib := range allowed {
+		libOS, libArch, ok := lib.nativesPlatform()
+		if ok {
+			if libOS != goos {
+				continue
+			}
+			if libArch == "" && goarch != "amd64" && archSpecific[baseName(lib)] {
+				continue
+			}
+			if libArch != "" && libArch != goarch {
+				continue
+			}
+		}
+		libs = append(libs, lib)
 	}
+	return libs
+}
+
+// DownloadClient downloads client jar using default Downloader. Use
+// Downloader.DownloadClient to configure download.
+func (v *Version) DownloadClient(versionsDir string) error {
+	return (&Downloader{}).DownloadClient(context.Background(), v, versionsDir)
+}
 
-	return v.Downloads.Client.Download(jarPath)
+// DownloadClient downloads client jar of v to versionsDir.
+func (d *Downloader) DownloadClient(ctx context.Context, v *Version, versionsDir string) error {
+	return d.run(ctx, []downloadJob{v.clientJob(versionsDir)})
 }
 
+func (v *Version) clientJob(versionsDir string) downloadJob {
+	return v.Downloads.Client.job("client jar", filepath.Join(versionsDir, v.jarID(), v.jarID()+".jar"))
+}
+
+// DownloadAssetsIndex downloads assets index using default Downloader. Use
+// Downloader.DownloadAssetsIndex to configure download.
 func (v *Version) DownloadAssetsIndex(assetsDir string) error {
-	assetsIndxPath := filepath.Join(assetsDir, "indexes", v.AssetIndex.ID+".json")
+	return (&Downloader{}).DownloadAssetsIndex(context.Background(), v, assetsDir)
+}
+
+// DownloadAssetsIndex downloads assets index of v to assetsDir.
+func (d *Downloader) DownloadAssetsIndex(ctx context.Context, v *Version, assetsDir string) error {
+	return d.run(ctx, []downloadJob{v.assetIndexJob(assetsDir)})
+}
+
+func (v *Version) assetIndexJob(assetsDir string) downloadJob {
+	return v.AssetIndex.job("assets index", filepath.Join(assetsDir, "indexes", v.AssetIndex.ID+".json"))
+}
 
-	if err := os.MkdirAll(filepath.Dir(assetsIndxPath), os.ModePerm); err != nil {
-		return errors.Wrap(err, "failed to create version directory")
+// logConfigPath returns path logging config of version is stored at or empty
+// string if version has none.
+func (v *Version) logConfigPath(assetsDir string) string {
+	if v.Logging.Client == nil || v.Logging.Client.File.ID == "" {
+		return ""
 	}
+	return filepath.Join(assetsDir, "log_configs", v.Logging.Client.File.ID)
+}
 
-	return v.AssetIndex.Download(assetsIndxPath)
+func (v *Version) logConfigJobs(assetsDir string) []downloadJob {
+	path := v.logConfigPath(assetsDir)
+	if path == "" {
+		return nil
+	}
+	return []downloadJob{v.Logging.Client.File.job("logging config", path)}
 }
 
+// DownloadAssets downloads asset objects using default Downloader. Use
+// Downloader.DownloadAssets to configure download.
 func (v *Version) DownloadAssets(assetsDir string) error {
+	return (&Downloader{}).DownloadAssets(context.Background(), v, assetsDir)
+}
+
+// DownloadAssets downloads asset objects listed in already downloaded assets
+// index of v to assetsDir.
+func (d *Downloader) DownloadAssets(ctx context.Context, v *Version, assetsDir string) error {
+	jobs, err := v.assetJobs(assetsDir)
+	if err != nil {
+		return err
+	}
+	return d.run(ctx, jobs)
+}
+
+// assetJobs returns list of asset objects referenced by already downloaded
+// assets index.
+func (v *Version) assetJobs(assetsDir string) ([]downloadJob, error) {
+	indx, err := v.readAssetIndex(assetsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	objectsDir := filepath.Join(assetsDir, "objects")
+	jobs := make([]downloadJob, 0, len(indx.Objects))
+	for path, asset := range indx.Objects {
+		jobs = append(jobs, asset.job("asset "+path, objectsDir))
+	}
+	return jobs, nil
+}
+
+// readAssetIndex parses downloaded assets index.
+func (v *Version) readAssetIndex(assetsDir string) (*AssetIndexContents, error) {
 	assetsIndxPath := filepath.Join(assetsDir, "indexes", v.AssetIndex.ID+".json")
 
 	assetsIndxBlob, err := ioutil.ReadFile(assetsIndxPath)
 	if err != nil {
-		return errors.Wrap(err, "failed to read assets index")
+		return nil, errors.Wrap(err, "failed to read assets index")
 	}
 
 	indx := AssetIndexContents{}
 	if err := json.Unmarshal(assetsIndxBlob, &indx); err != nil {
-		return errors.Wrap(err, "failed to parse assets index")
+		return nil, errors.Wrap(err, "failed to parse assets index")
 	}
+	return &indx, nil
+}
 
-	objectsDir := filepath.Join(assetsDir, "objects")
-	if err := os.MkdirAll(objectsDir, os.ModePerm); err != nil {
-		return errors.Wrap(err, "failed to create objects dir")
+// CopyLegacyAssets copies downloaded asset objects to the layout expected by
+// old versions: assets/virtual/<index id>/ for "virtual" indexes and
+// <gameDir>/resources/ for ones with "map_to_resources" set. Files are
+// hardlinked if possible.
+//
+// If gameDir is empty, resources directory is not populated. Does nothing for
+// modern versions and if assets index is not downloaded.
+func (v *Version) CopyLegacyAssets(assetsDir, gameDir string) error {
+	if v.AssetIndex.ID == "" {
+		return nil
+	}
+	indxPath := filepath.Join(assetsDir, "indexes", v.AssetIndex.ID+".json")
+	if _, err := os.Stat(indxPath); os.IsNotExist(err) {
+		return nil
+	}
+	indx, err := v.readAssetIndex(assetsDir)
+	if err != nil {
+		return err
 	}
 
-	for path, asset := range indx.Objects {
-		if err := asset.Download(objectsDir); err != nil {
-			return errors.Wrapf(err, "failed to download asset %s", path)
+	var targetDir string
+	switch {
+	case indx.MapToResources:
+		if gameDir == "" {
+			return nil
 		}
+		targetDir = filepath.Join(gameDir, "resources")
+	case indx.Virtual:
+		targetDir = filepath.Join(assetsDir, "virtual", v.AssetIndex.ID)
+	default:
+		return nil
 	}
-	return nil
-}
 
-func (a *Asset) Download(objectsDir string) error {
-	targetPath := filepath.Join(objectsDir, a.Hash[:2], a.Hash)
-	url := "http://resources.download.minecraft.net/" + a.Hash[:2] + "/" + a.Hash
-	return downloadAndCheck(targetPath, url, a.Hash)
-	// TODO: Copy to virtual/legacy for pre-1.7.2 versions.
+	objectsDir := filepath.Join(assetsDir, "objects")
+	for name, asset := range indx.Objects {
+		objectPath := filepath.Join(objectsDir, asset.Hash[:2], asset.Hash)
+		targetPath := filepath.Join(targetDir, filepath.FromSlash(name))
+		if err := linkOrCopy(objectPath, targetPath, asset.Size); err != nil {
+			return errors.Wrapf(err, "failed to copy asset %s", name)
+		}
+	}
+	return nil
 }
 
-func (a *Artifact) Download(targetPath string) error {
-	return downloadAndCheck(targetPath, a.URL, a.SHA1)
+// gameAssetsDir returns directory for ${game_assets} placeholder used by
+// legacy versions. assetsDir is returned if assets index is not downloaded
+// or uses default layout.
+func (v *Version) gameAssetsDir(assetsDir, gameDir string) string {
+	indx, err := v.readAssetIndex(assetsDir)
+	if err == nil && indx.MapToResources {
+		return filepath.Join(gameDir, "resources")
+	}
+	if err == nil && indx.Virtual {
+		return filepath.Join(assetsDir, "virtual", v.AssetIndex.ID)
+	}
+	return assetsDir
 }
 
-func downloadAndCheck(targetPath, url, expectedHash string) error {
-	if _, err := os.Stat(targetPath); !os.IsNotExist(err) {
-		hash := sha1.New()
-		f, err := os.Open(targetPath)
-		if err != nil {
-			return errors.Wrap(err, "failed to open file")
-		}
-
-		if _, err := io.Copy(hash, f); err != nil {
-			return errors.Wrap(err, "failed to read file")
-		}
-
-		if hex.EncodeToString(hash.Sum([]byte{})) == expectedHash {
+// linkOrCopy hardlinks src to dst, falling back to copying. Nothing is done
+// if dst already exists and has expected size.
+func linkOrCopy(src, dst string, size uint64) error {
+	if info, err := os.Stat(dst); err == nil {
+		if uint64(info.Size()) == size {
 			return nil
 		}
-		// if existing file doesn't matches hash - redownload.
+		if err := os.Remove(dst); err != nil {
+			return err
+		}
 	}
 
-	log.Println("Downloading", url+"...")
-	resp, err := http.Get(url)
-	if err != nil {
-		return errors.Wrap(err, "failed to start download")
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return errors.Wrap(err, "failed to create directory")
 	}
-	if resp.StatusCode != 200 {
-		return errors.New("failed to start download: HTTP " + resp.Status)
+	if err := os.Link(src, dst); err == nil {
+		return nil
 	}
 
-	if err := os.MkdirAll(filepath.Dir(targetPath), os.ModePerm); err != nil {
-		return errors.Wrap(err, "failed to create directory")
+	in, err := os.Open(src)
+	if err != nil {
+		return err
 	}
-
-	hash := sha1.New()
-
-	outFile, err := os.Create(targetPath + ".new")
+	defer in.Close()
+	out, err := os.Create(dst)
 	if err != nil {
-		return errors.Wrap(err, "failed to open file for writting")
+		return err
 	}
-	if _, err := io.Copy(io.MultiWriter(outFile, hash), resp.Body); err != nil {
-		os.Remove(targetPath + ".new")
-		return errors.Wrap(err, "failed to download file")
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
 	}
+	return out.Close()
+}
+
+// Download downloads asset object using default Downloader. Use
+// Downloader.DownloadAsset to configure download.
+func (a *Asset) Download(objectsDir string) error {
+	return (&Downloader{}).DownloadAsset(context.Background(), a, objectsDir)
+}
 
-	if hex.EncodeToString(hash.Sum([]byte{})) != expectedHash {
-		os.Remove(targetPath + ".new")
-		return errors.New("hash mismatch")
+// DownloadAsset downloads asset object to objectsDir.
+func (d *Downloader) DownloadAsset(ctx context.Context, a *Asset, objectsDir string) error {
+	return d.downloadAndCheck(ctx, a.job(a.Hash, objectsDir))
+}
+
+func (a *Asset) job(name, objectsDir string) downloadJob {
+	return downloadJob{
+		Name:       name,
+		TargetPath: filepath.Join(objectsDir, a.Hash[:2], a.Hash),
+		URL:        DefaultAssetsURL + a.Hash[:2] + "/" + a.Hash,
+		SHA1:       a.Hash,
+		Size:       a.Size,
 	}
+}
 
-	outFile.Close()
-	resp.Body.Close()
+// Download downloads artifact using default Downloader. Use
+// Downloader.DownloadArtifact to configure download.
+func (a *Artifact) Download(targetPath string) error {
+	return (&Downloader{}).DownloadArtifact(context.Background(), a, targetPath)
+}
 
-	if err := os.Rename(targetPath+".new", targetPath); err != nil {
-		return errors.Wrap(err, "failed to rename artifact file")
+// DownloadArtifact downloads artifact to targetPath.
+func (d *Downloader) DownloadArtifact(ctx context.Context, a *Artifact, targetPath string) error {
+	return d.downloadAndCheck(ctx, a.job(filepath.Base(targetPath), targetPath))
+}
+
+func (a *Artifact) job(name, targetPath string) downloadJob {
+	return downloadJob{
+		Name:       name,
+		TargetPath: targetPath,
+		URL:        a.URL,
+		SHA1:       a.SHA1,
+		Size:       a.Size,
 	}
-	return nil
 }
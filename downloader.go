@@ -0,0 +1,379 @@
+package gomine
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultConcurrency is the number of simultaneous downloads used by
+	// Downloader if Concurrency is not set.
+	DefaultConcurrency = 8
+
+	// DefaultRetries is the number of retries made by Downloader if
+	// Retries is not set.
+	DefaultRetries = 3
+
+	// DefaultRetryDelay is the delay before first retry used by Downloader
+	// if RetryDelay is not set.
+	DefaultRetryDelay = 1 * time.Second
+)
+
+// Downloader fetches and verifies batches of files using bounded pool of
+// workers.
+//
+// Zero value is ready to use.
+type Downloader struct {
+	// Concurrency limits number of files downloaded at the same time.
+	// Defaults to DefaultConcurrency.
+	Concurrency int
+
+	// Progress, if set, receives updates about downloaded files.
+	Progress Progress
+
+	// Net configures HTTP client and servers used for downloads. nil
+	// means defaults.
+	Net *NetConfig
+
+	// Retries is the number of times download is repeated after
+	// transient failure (network error, HTTP 5xx or 429) before falling
+	// back to the next source. Defaults to DefaultRetries, negative value
+	// disables retries.
+	Retries int
+
+	// RetryDelay is the delay before first retry, doubled after each
+	// attempt. Defaults to DefaultRetryDelay.
+	RetryDelay time.Duration
+}
+
+// downloadJob describes single file that should be present at TargetPath
+// with specified hash. Empty hash means that file is not verified.
+type downloadJob struct {
+	// Name is used in error messages.
+	Name       string
+	TargetPath string
+	URL        string
+	SHA1       string
+	Size       uint64
+}
+
+// DownloadErrors is returned by Downloader when one or more files from batch
+// failed to download. Other files are still downloaded.
+type DownloadErrors []error
+
+func (de DownloadErrors) Error() string {
+	if len(de) == 1 {
+		return de[0].Error()
+	}
+
+	msgs := make([]string, 0, len(de))
+	for _, err := range de {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d downloads failed: %s", len(de), strings.Join(msgs, "; "))
+}
+
+func (d *Downloader) concurrency() int {
+	if d.Concurrency <= 0 {
+		return DefaultConcurrency
+	}
+	return d.Concurrency
+}
+
+func (d *Downloader) retries() int {
+	if d.Retries == 0 {
+		return DefaultRetries
+	}
+	if d.Retries < 0 {
+		return 0
+	}
+	return d.Retries
+}
+
+func (d *Downloader) retryDelay() time.Duration {
+	if d.RetryDelay <= 0 {
+		return DefaultRetryDelay
+	}
+	return d.RetryDelay
+}
+
+// run downloads all files from jobs, returning DownloadErrors if any of them
+// failed.
+//
+// If ctx is cancelled, in-flight downloads are aborted and ctx.Err() is
+// returned.
+func (d *Downloader) run(ctx context.Context, jobs []downloadJob) error {
+	var (
+		wg      sync.WaitGroup
+		errsLck sync.Mutex
+		errs    DownloadErrors
+
+		sem  = make(chan struct{}, d.concurrency())
+		seen = make(map[string]bool, len(jobs))
+	)
+
+	for _, job := range jobs {
+		// Same file can be referenced multiple times (e.g. assets with
+		// identical contents), don't let two workers write it at once.
+		// It is still reported so progress totals match Planned.
+		if seen[job.TargetPath] {
+			if d.Progress != nil {
+				d.Progress.Skipped(job.TargetPath, job.Size)
+			}
+			continue
+		}
+		seen[job.TargetPath] = true
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(job downloadJob) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := d.downloadAndCheck(ctx, job); err != nil {
+				if d.Progress != nil {
+					d.Progress.Failed(job.TargetPath, err)
+				}
+				errsLck.Lock()
+				errs = append(errs, errors.Wrapf(err, "failed to download %s", job.Name))
+				errsLck.Unlock()
+			}
+		}(job)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if len(errs) != 0 {
+		sort.Slice(errs, func(i, j int) bool {
+			return errs[i].Error() < errs[j].Error()
+		})
+		return errs
+	}
+	return nil
+}
+
+// downloadAndCheck downloads single file unless it is already present with
+// matching hash. File is first written to targetPath + ".new" which is
+// removed if download is cancelled or hash doesn't match.
+//
+// Mirrors from Net are tried in order, falling back to the next one if
+// download or hash check fails. Transient errors are retried for each
+// source.
+func (d *Downloader) downloadAndCheck(ctx context.Context, job downloadJob) error {
+	targetPath := job.TargetPath
+	if _, err := os.Stat(targetPath); !os.IsNotExist(err) {
+		if job.SHA1 == "" {
+			// Nothing to check against, assume file is fine.
+			if d.Progress != nil {
+				d.Progress.Skipped(targetPath, job.Size)
+			}
+			return nil
+		}
+
+		hash, err := fileSHA1(targetPath)
+		if err != nil {
+			return err
+		}
+
+		if hash == job.SHA1 {
+			if d.Progress != nil {
+				d.Progress.Skipped(targetPath, job.Size)
+			}
+			return nil
+		}
+		// if existing file doesn't matches hash - redownload.
+	}
+
+	// Number of bytes of partial file already reported to Progress.
+	var reported int64
+
+	sources := d.Net.sources(job.URL)
+	errs := make([]string, 0, len(sources))
+	for _, src := range sources {
+		err := d.fetchRetrying(ctx, job, src.URL, &reported)
+		if err == nil {
+			if d.Progress != nil {
+				d.Progress.Done(targetPath, src.Name)
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			os.Remove(targetPath + ".new")
+			return ctx.Err()
+		}
+		if len(sources) == 1 {
+			return err
+		}
+		errs = append(errs, src.Name+": "+err.Error())
+	}
+	return errors.New("all sources failed: " + strings.Join(errs, "; "))
+}
+
+// transientError marks failures that may go away if request is repeated.
+type transientError struct {
+	error
+}
+
+// fetchRetrying calls fetch, retrying with exponential backoff while it fails
+// with transientError.
+func (d *Downloader) fetchRetrying(ctx context.Context, job downloadJob, url string, reported *int64) error {
+	delay := d.retryDelay()
+	for attempt := 0; ; attempt++ {
+		err := d.fetch(ctx, job, url, reported)
+		if _, ok := err.(transientError); !ok || attempt >= d.retries() {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// fetch downloads job's file from url and checks its hash.
+//
+// If targetPath + ".new" is left from previous attempt, download is resumed
+// from its end (if server supports range requests). If resumed file doesn't
+// match hash, partial file is assumed to be stale and download is restarted
+// from the beginning.
+//
+// *reported is the number of leading bytes of file already reported to
+// Progress, only bytes past it are reported so totals don't exceed file
+// size when download is resumed or restarted.
+func (d *Downloader) fetch(ctx context.Context, job downloadJob, url string, reported *int64) error {
+	targetPath := job.TargetPath
+	partPath := targetPath + ".new"
+
+	hash := sha1.New()
+	var offset int64
+	if partFile, err := os.Open(partPath); err == nil {
+		offset, err = io.Copy(hash, partFile)
+		partFile.Close()
+		if err != nil {
+			offset = 0
+			hash.Reset()
+		}
+	}
+
+	if d.Progress == nil {
+		log.Println("Downloading", url+"...")
+	}
+	resp, err := d.Net.getURL(ctx, url, offset)
+	if err != nil {
+		return transientError{errors.Wrap(err, "failed to start download")}
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	switch {
+	case resp.StatusCode == 206 && offset != 0 &&
+		strings.HasPrefix(resp.Header.Get("Content-Range"), "bytes "+strconv.FormatInt(offset, 10)+"-"):
+		flags |= os.O_APPEND
+		// Partial file may be left by previous run.
+		if d.Progress != nil && offset > *reported {
+			d.Progress.Downloaded(targetPath, uint64(offset-*reported))
+		}
+		*reported = offset
+	case resp.StatusCode == 200:
+		// Server ignored Range header (or there was nothing to resume).
+		flags |= os.O_TRUNC
+		hash.Reset()
+		offset = 0
+	case resp.StatusCode == 206 || resp.StatusCode == 416:
+		// Server can't continue from where we stopped, start over.
+		os.Remove(partPath)
+		return transientError{errors.New("failed to resume download: HTTP " + resp.Status)}
+	case resp.StatusCode >= 500 || resp.StatusCode == 429:
+		return transientError{errors.New("failed to start download: HTTP " + resp.Status)}
+	default:
+		return errors.New("failed to start download: HTTP " + resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(targetPath), os.ModePerm); err != nil {
+		return errors.Wrap(err, "failed to create directory")
+	}
+
+	outFile, err := os.OpenFile(partPath, flags, 0666)
+	if err != nil {
+		return errors.Wrap(err, "failed to open file for writting")
+	}
+	var out io.Writer = io.MultiWriter(outFile, hash)
+	if d.Progress != nil {
+		out = io.MultiWriter(out, &progressWriter{
+			path:     targetPath,
+			progress: d.Progress,
+			pos:      offset,
+			reported: reported,
+		})
+	}
+	_, err = io.Copy(out, resp.Body)
+	outFile.Close()
+	if err != nil {
+		// Partial file is kept so next attempt can resume from it.
+		return transientError{errors.Wrap(err, "failed to download file")}
+	}
+
+	if job.SHA1 != "" && hex.EncodeToString(hash.Sum([]byte{})) != job.SHA1 {
+		os.Remove(partPath)
+		if offset != 0 {
+			// Partial file may belong to older version of the file,
+			// start over. It is removed, so this is done only once.
+			return d.fetch(ctx, job, url, reported)
+		}
+		return errors.New("hash mismatch")
+	}
+
+	if err := os.Rename(partPath, targetPath); err != nil {
+		return errors.Wrap(err, "failed to rename artifact file")
+	}
+	return nil
+}
+
+// fileSHA1 returns hex-encoded SHA-1 hash of file contents.
+func fileSHA1(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open file")
+	}
+	defer f.Close()
+
+	hash := sha1.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", errors.Wrap(err, "failed to read file")
+	}
+	return hex.EncodeToString(hash.Sum([]byte{})), nil
+}
+
+// plannedSize returns total size of files in jobs.
+func plannedSize(jobs []downloadJob) uint64 {
+	var size uint64
+	for _, job := range jobs {
+		size += job.Size
+	}
+	return size
+}
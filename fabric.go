@@ -0,0 +1,132 @@
+package gomine
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// InstallFabric installs Fabric loader profile for specified game version
+// and downloads all files needed to run it (including parent vanilla
+// version).
+//
+// If loaderVersion is empty, latest loader version is used.
+func (r *Root) InstallFabric(gameVersion, loaderVersion string) (*Version, error) {
+	return r.InstallFabricContext(context.Background(), gameVersion, loaderVersion)
+}
+
+func (r *Root) InstallFabricContext(ctx context.Context, gameVersion, loaderVersion string) (*Version, error) {
+	return r.installLoaderProfile(ctx, r.Net.fabricMetaURL()+"/v2", gameVersion, loaderVersion)
+}
+
+// InstallQuilt is like InstallFabric but installs Quilt loader.
+func (r *Root) InstallQuilt(gameVersion, loaderVersion string) (*Version, error) {
+	return r.InstallQuiltContext(context.Background(), gameVersion, loaderVersion)
+}
+
+func (r *Root) InstallQuiltContext(ctx context.Context, gameVersion, loaderVersion string) (*Version, error) {
+	return r.installLoaderProfile(ctx, r.Net.quiltMetaURL()+"/v3", gameVersion, loaderVersion)
+}
+
+// installLoaderProfile fetches launcher profile from Fabric-compatible meta
+// API, stores it as local version and downloads it.
+func (r *Root) installLoaderProfile(ctx context.Context, metaURL, gameVersion, loaderVersion string) (*Version, error) {
+	if r.Offline {
+		return nil, errors.New("install: offline mode is enabled")
+	}
+
+	gameVersion = url.PathEscape(gameVersion)
+	if loaderVersion == "" {
+		var err error
+		loaderVersion, err = r.latestLoader(ctx, metaURL+"/versions/loader/"+gameVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	profileURL := metaURL + "/versions/loader/" + gameVersion + "/" + url.PathEscape(loaderVersion) + "/profile/json"
+	resp, _, err := r.Net.get(ctx, profileURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to download loader profile")
+	}
+	defer resp.Body.Close()
+	blob, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to download loader profile")
+	}
+
+	profile, err := ReadVersionJSON(blob)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse loader profile")
+	}
+	if profile.ID == "" || profile.InheritsFrom == "" {
+		return nil, errors.New("install: loader profile is missing id or inheritsFrom")
+	}
+
+	if err := r.addLocalVersion(ctx, profile.ID, blob); err != nil {
+		return nil, err
+	}
+
+	ver, err := r.GetVersionContext(ctx, profile.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.UpdateVersionContext(ctx, ver); err != nil {
+		return nil, err
+	}
+	return ver, nil
+}
+
+// latestLoader returns first loader version listed by meta API at listURL.
+func (r *Root) latestLoader(ctx context.Context, listURL string) (string, error) {
+	resp, _, err := r.Net.get(ctx, listURL)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get loader versions")
+	}
+	defer resp.Body.Close()
+
+	var loaders []struct {
+		Loader struct {
+			Version string `json:"version"`
+		} `json:"loader"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&loaders); err != nil {
+		return "", errors.Wrap(err, "failed to parse loader versions")
+	}
+	if len(loaders) == 0 {
+		return "", errors.New("install: no loader versions available")
+	}
+	return loaders[0].Loader.Version, nil
+}
+
+// addLocalVersion writes version JSON to versions directory and makes it
+// known to Root.
+func (r *Root) addLocalVersion(ctx context.Context, id string, blob []byte) error {
+	if r.knownVersions == nil {
+		if _, err := r.VersionsContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	versionDir := filepath.Join(r.VersionsDir(), id)
+	if err := os.MkdirAll(versionDir, os.ModePerm); err != nil {
+		return errors.Wrap(err, "failed to create version directory")
+	}
+	if err := ioutil.WriteFile(filepath.Join(versionDir, id+".json"), blob, os.ModePerm); err != nil {
+		return errors.Wrap(err, "failed to write version info")
+	}
+
+	meta := r.knownVersions[id]
+	meta.ID = id
+	meta.Installed = true
+	if meta.Type == "" {
+		meta.Type = "local"
+	}
+	r.knownVersions[id] = meta
+	return nil
+}
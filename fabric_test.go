@@ -0,0 +1,135 @@
+package gomine
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testVanillaJSON = `{"assetIndex": {"id": "5", "sha1": "", "size": 13, "totalSize": 0, "url": "SERVER/5.json"}, "assets": "5", "downloads": {"client": {"sha1": "", "size": 6, "url": "SERVER/client.jar"}}, "id": "1.20.1", "libraries": [{"name": "com.mojang:brigadier:1.1.8"}], "mainClass": "net.minecraft.client.main.Main", "minimumLauncherVersion": 21, "type": "release"}`
+
+	testFabricProfile = `{"id":"fabric-loader-0.14.21-1.20.1","inheritsFrom":"1.20.1","type":"release","mainClass":"net.fabricmc.loader.impl.launch.knot.KnotClient","arguments":{"game":[],"jvm":["-DFabricMcEmu= net.minecraft.client.main.Main "]},"libraries":[{"name":"net.fabricmc:sponge-mixin:0.12.5+mixin.0.8.5","url":"SERVER/maven/"},{"name":"net.fabricmc:fabric-loader:0.14.21","url":"SERVER/maven"}]}`
+)
+
+// fakeMetaServer serves versions manifest, vanilla version, Fabric meta API
+// and any file under /maven/ and /libraries/. Requested paths are recorded.
+type fakeMetaServer struct {
+	*httptest.Server
+
+	lck       sync.Mutex
+	requested []string
+}
+
+func newFakeMetaServer() *fakeMetaServer {
+	s := &fakeMetaServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.lck.Lock()
+		s.requested = append(s.requested, req.URL.Path)
+		s.lck.Unlock()
+
+		switch {
+		case req.URL.Path == "/version_manifest.json":
+			w.Write([]byte(`{"latest": {"release": "1.20.1"}, "versions": [{"id": "1.20.1", "type": "release", "url": "` + s.URL + `/1.20.1.json"}]}`))
+		case req.URL.Path == "/1.20.1.json":
+			w.Write([]byte(strings.Replace(testVanillaJSON, "SERVER", s.URL, -1)))
+		case req.URL.Path == "/5.json":
+			w.Write([]byte(`{"objects": {}}`))
+		case req.URL.Path == "/fabric/v2/versions/loader/1.20.1":
+			w.Write([]byte(`[{"loader": {"version": "0.14.21"}}, {"loader": {"version": "0.14.20"}}]`))
+		case req.URL.Path == "/fabric/v2/versions/loader/1.20.1/0.14.21/profile/json":
+			w.Write([]byte(strings.Replace(testFabricProfile, "SERVER", s.URL, -1)))
+		case req.URL.Path == "/client.jar",
+			strings.HasPrefix(req.URL.Path, "/maven/"),
+			strings.HasPrefix(req.URL.Path, "/libraries/"):
+			w.Write([]byte("jar"))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	return s
+}
+
+func TestInstallFabric(t *testing.T) {
+	srv := newFakeMetaServer()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "gomine-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := UseRoot(dir)
+	r.Net = NetConfig{
+		ManifestURL:   srv.URL + "/version_manifest.json",
+		FabricMetaURL: srv.URL + "/fabric",
+		LibrariesURL:  srv.URL + "/libraries/",
+	}
+
+	ver, err := r.InstallFabric("1.20.1", "")
+	if err != nil {
+		t.Fatal("InstallFabric:", err)
+	}
+
+	const id = "fabric-loader-0.14.21-1.20.1"
+	blob, err := ioutil.ReadFile(filepath.Join(dir, "versions", id, id+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(blob) != strings.Replace(testFabricProfile, "SERVER", srv.URL, -1) {
+		t.Errorf("written profile differs from served one:\n%s", blob)
+	}
+
+	if ver.ID != id {
+		t.Errorf("ID = %q, want %q", ver.ID, id)
+	}
+	if ver.InheritsFrom != "" {
+		t.Errorf("InheritsFrom = %q, want it resolved", ver.InheritsFrom)
+	}
+	if ver.MainClass != "net.fabricmc.loader.impl.launch.knot.KnotClient" {
+		t.Errorf("MainClass = %q, want loader one", ver.MainClass)
+	}
+	if ver.jarID() != "1.20.1" || ver.AssetIndex.ID != "5" {
+		t.Errorf("client jar %q and assets %q are not taken from parent", ver.jarID(), ver.AssetIndex.ID)
+	}
+	var libs []string
+	for _, lib := range ver.Libraries {
+		libs = append(libs, lib.Name)
+	}
+	wantLibs := []string{
+		"net.fabricmc:sponge-mixin:0.12.5+mixin.0.8.5",
+		"net.fabricmc:fabric-loader:0.14.21",
+		"com.mojang:brigadier:1.1.8",
+	}
+	if strings.Join(libs, " ") != strings.Join(wantLibs, " ") {
+		t.Errorf("Libraries = %v, want %v", libs, wantLibs)
+	}
+
+	srv.lck.Lock()
+	requested := append([]string{}, srv.requested...)
+	srv.lck.Unlock()
+	sort.Strings(requested)
+	for _, path := range []string{
+		// Library with repository URL, with and without trailing slash.
+		"/maven/net/fabricmc/sponge-mixin/0.12.5+mixin.0.8.5/sponge-mixin-0.12.5+mixin.0.8.5.jar",
+		"/maven/net/fabricmc/fabric-loader/0.14.21/fabric-loader-0.14.21.jar",
+		// Library without URL uses NetConfig.LibrariesURL.
+		"/libraries/com/mojang/brigadier/1.1.8/brigadier-1.1.8.jar",
+	} {
+		i := sort.SearchStrings(requested, path)
+		if i == len(requested) || requested[i] != path {
+			t.Errorf("%s is not requested, requested: %v", path, requested)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "libraries", "net", "fabricmc", "fabric-loader", "0.14.21", "fabric-loader-0.14.21.jar")); err != nil {
+		t.Error("loader library is not downloaded:", err)
+	}
+}
@@ -0,0 +1,470 @@
+package gomine
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// forgeInstallProfile is the install_profile.json of Forge (1.13+) and
+// NeoForge installers.
+type forgeInstallProfile struct {
+	Spec      int    `json:"spec"`
+	Version   string `json:"version"`
+	JSON      string `json:"json"`
+	Minecraft string `json:"minecraft"`
+	Data      map[string]struct {
+		Client string `json:"client"`
+		Server string `json:"server"`
+	} `json:"data"`
+	Processors []forgeProcessor `json:"processors"`
+	Libraries  []Lib            `json:"libraries"`
+
+	// Set only by legacy (1.12 and older) installers.
+	Install json.RawMessage `json:"install"`
+}
+
+type forgeProcessor struct {
+	Jar       string            `json:"jar"`
+	Classpath []string          `json:"classpath"`
+	Args      []string          `json:"args"`
+	Outputs   map[string]string `json:"outputs"`
+	Sides     []string          `json:"sides"`
+}
+
+// InstallForge installs version from Forge or NeoForge installer jar.
+//
+// Parent vanilla version and all libraries are downloaded, installer
+// processors are run using Java required by Minecraft version to produce
+// patched client. Files written by processors are recorded so GC doesn't
+// remove them. Only installers for Minecraft 1.13 and newer are supported.
+func (r *Root) InstallForge(installerPath string) (*Version, error) {
+	return r.InstallForgeContext(context.Background(), installerPath)
+}
+
+func (r *Root) InstallForgeContext(ctx context.Context, installerPath string) (*Version, error) {
+	installerPath, err := filepath.Abs(installerPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get abs path")
+	}
+
+	installer, err := zip.OpenReader(installerPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open installer")
+	}
+	defer installer.Close()
+
+	profileBlob, err := readZipFile(&installer.Reader, "install_profile.json")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read install profile")
+	}
+	profile := forgeInstallProfile{}
+	if err := json.Unmarshal(profileBlob, &profile); err != nil {
+		return nil, errors.Wrap(err, "failed to parse install profile")
+	}
+	if profile.Install != nil || profile.Minecraft == "" {
+		return nil, errors.New("install: legacy Forge installers are not supported")
+	}
+	if profile.JSON == "" {
+		profile.JSON = "/version.json"
+	}
+
+	versionBlob, err := readZipFile(&installer.Reader, strings.TrimPrefix(profile.JSON, "/"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read version info")
+	}
+	versionInfo, err := ReadVersionJSON(versionBlob)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse version info")
+	}
+
+	vanilla, err := r.GetVersionContext(ctx, profile.Minecraft)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get version %s", profile.Minecraft)
+	}
+	if err := r.UpdateVersionContext(ctx, vanilla); err != nil {
+		return nil, errors.Wrapf(err, "failed to update version %s", profile.Minecraft)
+	}
+
+	// Both installer and version libraries may be packaged in installer.
+	allLibs := append(append([]Lib{}, profile.Libraries...), versionInfo.Libraries...)
+	if err := r.extractInstallerLibs(&installer.Reader, allLibs); err != nil {
+		return nil, err
+	}
+	installerVer := Version{Libraries: profile.Libraries}
+	jobs, err := installerVer.libraryJobs(r.LibrariesDir(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.downloader().run(ctx, jobs); err != nil {
+		return nil, err
+	}
+
+	if err := r.addLocalVersion(ctx, versionInfo.ID, versionBlob); err != nil {
+		return nil, err
+	}
+	ver, err := r.GetVersionContext(ctx, versionInfo.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.UpdateVersionContext(ctx, ver); err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := ioutil.TempDir("", "gomine-forge")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temporary directory")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	data, err := r.forgeData(&installer.Reader, &profile, vanilla, installerPath, tmpDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var javaBin string
+	for i, proc := range profile.Processors {
+		if !proc.forClient() {
+			continue
+		}
+		if javaBin == "" {
+			if javaBin, err = r.javaForVersion(vanilla); err != nil {
+				return nil, errors.Wrap(err, "failed to detect java")
+			}
+		}
+		if err := r.runProcessor(ctx, javaBin, proc, data); err != nil {
+			return nil, errors.Wrapf(err, "processor %d (%s)", i, proc.Jar)
+		}
+	}
+
+	if err := r.writeProcessorOutputs(ver.ID, &profile, data); err != nil {
+		return nil, err
+	}
+	return ver, nil
+}
+
+// processorOutputsPath returns path to the list of files produced by
+// installer processors for version. These files are not listed in version
+// info, so the list is used by GC to keep them.
+func (r *Root) processorOutputsPath(id string) string {
+	return filepath.Join(r.VersionsDir(), id, id+".outputs.json")
+}
+
+// writeProcessorOutputs saves outputs of client processors and libraries
+// referenced by data entries (processors write some of them without listing
+// as outputs) to processorOutputsPath. Paths are stored relative to
+// LauncherDir.
+func (r *Root) writeProcessorOutputs(id string, profile *forgeInstallProfile, data map[string]string) error {
+	var paths []string
+	for key, entry := range profile.Data {
+		if strings.HasPrefix(entry.Client, "[") && strings.HasSuffix(entry.Client, "]") {
+			paths = append(paths, data[key])
+		}
+	}
+	for _, proc := range profile.Processors {
+		if !proc.forClient() {
+			continue
+		}
+		for rawPath := range proc.Outputs {
+			path, err := r.substituteProcessorArg(rawPath, data)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, path)
+		}
+	}
+
+	relPaths := make([]string, 0, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		rel, err := filepath.Rel(r.LauncherDir, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || seen[rel] {
+			continue
+		}
+		seen[rel] = true
+		relPaths = append(relPaths, filepath.ToSlash(rel))
+	}
+	sort.Strings(relPaths)
+
+	blob, err := json.Marshal(relPaths)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(r.processorOutputsPath(id), blob, os.ModePerm); err != nil {
+		return errors.Wrap(err, "failed to write processor outputs list")
+	}
+	return nil
+}
+
+// readProcessorOutputs returns absolute paths of files listed in
+// processorOutputsPath. nil is returned if there is no list.
+func (r *Root) readProcessorOutputs(id string) ([]string, error) {
+	blob, err := ioutil.ReadFile(r.processorOutputsPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to read processor outputs list")
+	}
+	var relPaths []string
+	if err := json.Unmarshal(blob, &relPaths); err != nil {
+		return nil, errors.Wrap(err, "failed to parse processor outputs list")
+	}
+	paths := make([]string, 0, len(relPaths))
+	for _, rel := range relPaths {
+		paths = append(paths, filepath.Join(r.LauncherDir, filepath.FromSlash(rel)))
+	}
+	return paths, nil
+}
+
+// extractInstallerLibs copies libraries bundled in installer's maven/
+// directory to libraries directory. Bundled libraries have empty URL.
+func (r *Root) extractInstallerLibs(installer *zip.Reader, libs []Lib) error {
+	for _, lib := range libs {
+		if lib.Downloads.MainJar == nil || lib.Downloads.MainJar.URL != "" {
+			continue
+		}
+		path, err := lib.SavePath()
+		if err != nil {
+			return errors.Wrapf(err, "failed to get save path for %s", lib.Name)
+		}
+
+		blob, err := readZipFile(installer, "maven/"+filepath.ToSlash(path))
+		if err != nil {
+			// Not bundled, produced by processors.
+			continue
+		}
+		targetPath := filepath.Join(r.LibrariesDir(), path)
+		if err := os.MkdirAll(filepath.Dir(targetPath), os.ModePerm); err != nil {
+			return errors.Wrap(err, "failed to create directory")
+		}
+		if err := ioutil.WriteFile(targetPath, blob, os.ModePerm); err != nil {
+			return errors.Wrapf(err, "failed to extract %s", lib.Name)
+		}
+	}
+	return nil
+}
+
+// forgeData builds values for {KEY} placeholders in processor arguments.
+// Files referenced by data entries are extracted from installer to tmpDir.
+func (r *Root) forgeData(installer *zip.Reader, profile *forgeInstallProfile, vanilla *Version, installerPath, tmpDir string) (map[string]string, error) {
+	data := map[string]string{
+		"SIDE":              "client",
+		"MINECRAFT_JAR":     filepath.Join(r.VersionsDir(), vanilla.jarID(), vanilla.jarID()+".jar"),
+		"MINECRAFT_VERSION": profile.Minecraft,
+		"ROOT":              r.LauncherDir,
+		"INSTALLER":         installerPath,
+		"LIBRARY_DIR":       r.LibrariesDir(),
+	}
+
+	for key, entry := range profile.Data {
+		value := entry.Client
+		switch {
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			path, err := mavenPath(value[1 : len(value)-1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "data entry %s", key)
+			}
+			data[key] = filepath.Join(r.LibrariesDir(), path)
+		case strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"):
+			data[key] = value[1 : len(value)-1]
+		default:
+			blob, err := readZipFile(installer, strings.TrimPrefix(value, "/"))
+			if err != nil {
+				return nil, errors.Wrapf(err, "data entry %s", key)
+			}
+			path := filepath.Join(tmpDir, filepath.FromSlash(strings.TrimPrefix(value, "/")))
+			if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+				return nil, errors.Wrap(err, "failed to create directory")
+			}
+			if err := ioutil.WriteFile(path, blob, os.ModePerm); err != nil {
+				return nil, errors.Wrapf(err, "failed to extract %s", value)
+			}
+			data[key] = path
+		}
+	}
+	return data, nil
+}
+
+func (p *forgeProcessor) forClient() bool {
+	if len(p.Sides) == 0 {
+		return true
+	}
+	for _, side := range p.Sides {
+		if side == "client" {
+			return true
+		}
+	}
+	return false
+}
+
+// substituteProcessorArg replaces {KEY} placeholders with values from data
+// and [coords] with paths to libraries.
+func (r *Root) substituteProcessorArg(arg string, data map[string]string) (string, error) {
+	if strings.HasPrefix(arg, "[") && strings.HasSuffix(arg, "]") {
+		path, err := mavenPath(arg[1 : len(arg)-1])
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(r.LibrariesDir(), path), nil
+	}
+	if strings.HasPrefix(arg, "'") && strings.HasSuffix(arg, "'") {
+		return arg[1 : len(arg)-1], nil
+	}
+
+	var res strings.Builder
+	for {
+		start := strings.Index(arg, "{")
+		if start == -1 {
+			break
+		}
+		end := strings.Index(arg[start:], "}")
+		if end == -1 {
+			break
+		}
+		key := arg[start+1 : start+end]
+		value, ok := data[key]
+		if !ok {
+			return "", errors.New("unknown data key: " + key)
+		}
+		res.WriteString(arg[:start])
+		res.WriteString(value)
+		arg = arg[start+end+1:]
+	}
+	res.WriteString(arg)
+	return res.String(), nil
+}
+
+// runProcessor executes processor jar unless all its outputs are already
+// present, and checks produced outputs.
+func (r *Root) runProcessor(ctx context.Context, javaBin string, proc forgeProcessor, data map[string]string) error {
+	outputs := make(map[string]string, len(proc.Outputs))
+	for rawPath, rawHash := range proc.Outputs {
+		path, err := r.substituteProcessorArg(rawPath, data)
+		if err != nil {
+			return err
+		}
+		hash, err := r.substituteProcessorArg(rawHash, data)
+		if err != nil {
+			return err
+		}
+		outputs[path] = hash
+	}
+	if len(outputs) != 0 && checkOutputs(outputs) == nil {
+		return nil
+	}
+
+	jarPath, err := mavenPath(proc.Jar)
+	if err != nil {
+		return err
+	}
+	jarPath = filepath.Join(r.LibrariesDir(), jarPath)
+	mainClass, err := jarMainClass(jarPath)
+	if err != nil {
+		return err
+	}
+
+	classPath := make([]string, 0, len(proc.Classpath)+1)
+	classPath = append(classPath, jarPath)
+	for _, coords := range proc.Classpath {
+		path, err := mavenPath(coords)
+		if err != nil {
+			return err
+		}
+		classPath = append(classPath, filepath.Join(r.LibrariesDir(), path))
+	}
+
+	args := []string{"-cp", strings.Join(classPath, classPathSeparator()), mainClass}
+	for _, rawArg := range proc.Args {
+		arg, err := r.substituteProcessorArg(rawArg, data)
+		if err != nil {
+			return err
+		}
+		args = append(args, arg)
+	}
+
+	out, err := exec.CommandContext(ctx, javaBin, args...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "processor failed, output:\n%s", out)
+	}
+
+	return checkOutputs(outputs)
+}
+
+func checkOutputs(outputs map[string]string) error {
+	for path, expectedHash := range outputs {
+		hash, err := fileSHA1(path)
+		if err != nil {
+			return err
+		}
+		if hash != expectedHash {
+			return errors.New("hash mismatch for " + path)
+		}
+	}
+	return nil
+}
+
+// jarMainClass returns Main-Class attribute from jar manifest.
+func jarMainClass(jarPath string) (string, error) {
+	jar, err := zip.OpenReader(jarPath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open jar")
+	}
+	defer jar.Close()
+
+	manifest, err := readZipFile(&jar.Reader, "META-INF/MANIFEST.MF")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read manifest of %s", jarPath)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "Main-Class:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "Main-Class:")), nil
+		}
+	}
+	return "", errors.New("no Main-Class in manifest of " + jarPath)
+}
+
+func readZipFile(r *zip.Reader, name string) ([]byte, error) {
+	for _, file := range r.File {
+		if file.Name != name {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return ioutil.ReadAll(rc)
+	}
+	return nil, errors.New("no such file in archive: " + name)
+}
+
+// mavenPath converts Maven coordinates into path relative to repository
+// root.
+func mavenPath(coords string) (string, error) {
+	coord, err := ParseCoord(coords)
+	if err != nil {
+		return "", err
+	}
+	return coord.Path(), nil
+}
+
+func classPathSeparator() string {
+	if runtime.GOOS == "windows" {
+		return ";"
+	}
+	return ":"
+}
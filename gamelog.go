@@ -0,0 +1,102 @@
+package gomine
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// LogRecord is a single message from game output.
+//
+// Output lines that are not log4j events (printed before logging is
+// initialized or by versions without logging config) are represented by
+// records with only Message set.
+type LogRecord struct {
+	Time    time.Time
+	Level   string
+	Thread  string
+	Logger  string
+	Message string
+
+	// Throwable is the stack trace of exception logged with message.
+	Throwable string
+}
+
+// String formats record the same way as game does in its text log:
+// [15:04:05] [thread/LEVEL]: message
+func (rec LogRecord) String() string {
+	if rec.Level == "" {
+		return rec.Message
+	}
+	s := "[" + rec.Time.Format("15:04:05") + "] [" + rec.Thread + "/" + rec.Level + "]: " + rec.Message
+	if rec.Throwable != "" {
+		s += "\n" + strings.TrimRight(rec.Throwable, "\n")
+	}
+	return s
+}
+
+// log4jParser assembles log4j events written by XMLLayout (used in Mojang
+// logging configs) from output lines.
+type log4jParser struct {
+	event []string
+}
+
+// line processes single output line. ok is false if line is a part of
+// incomplete event or is empty.
+func (lp *log4jParser) line(line string) (rec LogRecord, ok bool) {
+	// XMLLayout separates events with empty lines.
+	if lp.event == nil && strings.TrimSpace(line) == "" {
+		return LogRecord{}, false
+	}
+	if lp.event == nil && !strings.Contains(line, "<log4j:Event") {
+		return LogRecord{Message: line}, true
+	}
+
+	lp.event = append(lp.event, line)
+	if !strings.Contains(line, "</log4j:Event>") {
+		return LogRecord{}, false
+	}
+	text := strings.Join(lp.event, "\n")
+	lp.event = nil
+	return parseLog4jEvent(text), true
+}
+
+// flush returns lines of incomplete event as record if there are any.
+func (lp *log4jParser) flush() (rec LogRecord, ok bool) {
+	if lp.event == nil {
+		return LogRecord{}, false
+	}
+	text := strings.Join(lp.event, "\n")
+	lp.event = nil
+	return LogRecord{Message: text}, true
+}
+
+// parseLog4jEvent parses event like the following one:
+//
+//	<log4j:Event logger="net.minecraft.client.Minecraft" timestamp="1686576351000" level="INFO" thread="Render thread">
+//	  <log4j:Message><![CDATA[Setting user: Player]]></log4j:Message>
+//	</log4j:Event>
+//
+// If event is malformed, it is returned as is in Message.
+func parseLog4jEvent(text string) LogRecord {
+	event := struct {
+		Logger    string `xml:"logger,attr"`
+		Timestamp int64  `xml:"timestamp,attr"`
+		Level     string `xml:"level,attr"`
+		Thread    string `xml:"thread,attr"`
+		Message   string `xml:"Message"`
+		Throwable string `xml:"Throwable"`
+	}{}
+	if err := xml.Unmarshal([]byte(text), &event); err != nil {
+		return LogRecord{Message: text}
+	}
+
+	return LogRecord{
+		Time:      time.Unix(0, event.Timestamp*int64(time.Millisecond)),
+		Level:     event.Level,
+		Thread:    event.Thread,
+		Logger:    event.Logger,
+		Message:   event.Message,
+		Throwable: event.Throwable,
+	}
+}
@@ -0,0 +1,210 @@
+package gomine
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// GCReport lists files removed (or to be removed in dry-run mode) by Root.GC.
+type GCReport struct {
+	Files []string
+
+	// Bytes is the total size of Files.
+	Bytes uint64
+}
+
+// GC removes files in libraries, assets objects and versions directories that
+// are not referenced by any installed version. If dryRun is set, nothing is
+// removed, only report is built.
+//
+// Natives for all operating systems are considered referenced, so root can
+// be shared between machines.
+func (r *Root) GC(dryRun bool) (*GCReport, error) {
+	referenced, err := r.referencedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &GCReport{}
+	collect := func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || referenced[path] {
+			return nil
+		}
+		// Extracted natives are not tracked individually, keep them for
+		// installed versions.
+		if rel, err := filepath.Rel(r.VersionsDir(), path); err == nil {
+			parts := strings.SplitN(rel, string(os.PathSeparator), 3)
+			if len(parts) == 3 && referenced[filepath.Join(r.VersionsDir(), parts[0], parts[1])] {
+				return nil
+			}
+		}
+
+		report.Files = append(report.Files, path)
+		report.Bytes += uint64(info.Size())
+		return nil
+	}
+
+	objectsDir := filepath.Join(r.AssetsDir(), "objects")
+	for _, dir := range []string{r.LibrariesDir(), objectsDir, r.VersionsDir()} {
+		if err := filepath.Walk(dir, collect); err != nil {
+			return nil, errors.Wrap(err, "failed to list files")
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	for _, path := range report.Files {
+		if err := os.Remove(path); err != nil {
+			return report, errors.Wrap(err, "failed to remove file")
+		}
+	}
+	for _, dir := range []string{r.LibrariesDir(), objectsDir, r.VersionsDir()} {
+		if err := removeEmptyDirs(dir); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// referencedFiles returns set of files referenced by installed versions.
+// Natives directories of installed versions are included too.
+func (r *Root) referencedFiles() (map[string]bool, error) {
+	referenced := make(map[string]bool)
+
+	versionDirs, err := ioutil.ReadDir(r.VersionsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "failed to list versions")
+	}
+	for _, dir := range versionDirs {
+		id := dir.Name()
+		versionDir := filepath.Join(r.VersionsDir(), id)
+		blob, err := ioutil.ReadFile(filepath.Join(versionDir, id+".json"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Not an installed version.
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to read version info for %s", id)
+		}
+		ver, err := ReadVersionJSON(blob)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse version info for %s", id)
+		}
+
+		referenced[filepath.Join(versionDir, id+".json")] = true
+		referenced[filepath.Join(versionDir, id+".jar")] = true
+		referenced[filepath.Join(versionDir, "natives")] = true
+
+		for _, lib := range ver.Libraries {
+			path, err := lib.SavePath()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get save path for %s", lib.Name)
+			}
+			referenced[filepath.Join(r.LibrariesDir(), path)] = true
+
+			// Same as NativeSavePath but for all OSes.
+			coord, err := lib.Coord()
+			if err != nil {
+				return nil, err
+			}
+			for _, nativeStr := range lib.NativeSuffixes {
+				for _, arch := range []string{"32", "64"} {
+					classifier := strings.Replace(nativeStr, "${arch}", arch, -1)
+					referenced[filepath.Join(r.LibrariesDir(), coord.WithClassifier(classifier).Path())] = true
+				}
+			}
+		}
+
+		// Files produced by Forge installer processors.
+		outputs, err := r.readProcessorOutputs(id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "version %s", id)
+		}
+		if outputs != nil {
+			referenced[r.processorOutputsPath(id)] = true
+		}
+		for _, path := range outputs {
+			referenced[path] = true
+		}
+
+		if err := r.referenceAssets(ver, referenced); err != nil {
+			return nil, err
+		}
+	}
+	return referenced, nil
+}
+
+func (r *Root) referenceAssets(ver *Version, referenced map[string]bool) error {
+	if ver.AssetIndex.ID == "" {
+		return nil
+	}
+	indexBlob, err := ioutil.ReadFile(filepath.Join(r.AssetsDir(), "indexes", ver.AssetIndex.ID+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(err, "failed to read assets index")
+	}
+	indx := AssetIndexContents{}
+	if err := json.Unmarshal(indexBlob, &indx); err != nil {
+		return errors.Wrap(err, "failed to parse assets index")
+	}
+
+	objectsDir := filepath.Join(r.AssetsDir(), "objects")
+	for _, asset := range indx.Objects {
+		if len(asset.Hash) < 2 {
+			continue
+		}
+		referenced[filepath.Join(objectsDir, asset.Hash[:2], asset.Hash)] = true
+	}
+	return nil
+}
+
+// removeEmptyDirs removes all empty directories inside root (but not root
+// itself).
+func removeEmptyDirs(root string) error {
+	var dirs []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() && path != root {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list directories")
+	}
+
+	// Walk lists parents before children, so go backwards to remove
+	// nested empty directories first.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		entries, err := ioutil.ReadDir(dirs[i])
+		if err != nil {
+			return errors.Wrap(err, "failed to list directory")
+		}
+		if len(entries) == 0 {
+			if err := os.Remove(dirs[i]); err != nil {
+				return errors.Wrap(err, "failed to remove directory")
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package gomine
+
+// jarID returns ID of version whose client jar is used by v.
+func (v *Version) jarID() string {
+	if v.Jar != "" {
+		return v.Jar
+	}
+	return v.ID
+}
+
+// libKey returns library name without version, so different versions of the
+// same library have the same key.
+func libKey(name string) string {
+	coord, err := ParseCoord(name)
+	if err != nil {
+		return name
+	}
+	coord.Version = ""
+	return coord.String()
+}
+
+// inheritVersion merges child version with its parent.
+//
+// Child libraries take precedence over parent ones with the same package and
+// name. Arguments are concatenated, other fields are taken from parent only if
+// they are not set in child.
+func inheritVersion(parent, child *Version) *Version {
+	res := *child
+	res.InheritsFrom = ""
+
+	res.Libraries = make([]Lib, 0, len(child.Libraries)+len(parent.Libraries))
+	seen := make(map[string]bool, len(child.Libraries))
+	for _, lib := range child.Libraries {
+		seen[libKey(lib.Name)] = true
+		res.Libraries = append(res.Libraries, lib)
+	}
+	for _, lib := range parent.Libraries {
+		if seen[libKey(lib.Name)] {
+			continue
+		}
+		res.Libraries = append(res.Libraries, lib)
+	}
+
+	if child.MinecraftArguments != "" {
+		// Legacy arguments string always contains complete command line.
+		res.GameArgs = child.GameArgs
+	} else {
+		res.MinecraftArguments = parent.MinecraftArguments
+		res.GameArgs = append(append([]Argument{}, parent.GameArgs...), child.GameArgs...)
+	}
+	// Legacy parent relies on defaults that would be dropped once child
+	// arguments are added.
+	parentJVMArgs := parent.JVMArgs
+	if len(parentJVMArgs) == 0 {
+		parentJVMArgs = defaultJVMArgs
+	}
+	res.JVMArgs = append(append([]Argument{}, parentJVMArgs...), child.JVMArgs...)
+
+	if res.MainClass == "" {
+		res.MainClass = parent.MainClass
+	}
+	if res.AssetIndex.ID == "" {
+		res.AssetIndex = parent.AssetIndex
+	}
+	if res.Type == "" {
+		res.Type = parent.Type
+	}
+	if res.JavaVersion.Component == "" && res.JavaVersion.MajorVersion == 0 {
+		res.JavaVersion = parent.JavaVersion
+	}
+	if res.Logging.Client == nil {
+		res.Logging = parent.Logging
+	}
+	if res.Downloads.Client.URL == "" {
+		res.Downloads = parent.Downloads
+		if res.Jar == "" {
+			res.Jar = parent.jarID()
+		}
+	}
+	return &res
+}
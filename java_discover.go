@@ -0,0 +1,259 @@
+package gomine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// javaProbeTimeout limits time spent waiting for single java executable to
+// report its properties.
+const javaProbeTimeout = 10 * time.Second
+
+// JavaInstallation describes Java found by DiscoverJavaInstallations.
+type JavaInstallation struct {
+	// Path is the location of java executable.
+	Path string
+
+	// Home is the value of java.home property.
+	Home string
+
+	// Version is the value of java.version property, e.g. "1.8.0_382" or
+	// "17.0.8".
+	Version      string
+	MajorVersion int
+
+	Vendor string
+
+	// Arch is the value of os.arch property.
+	Arch    string
+	Is64Bit bool
+}
+
+// DiscoverJavaInstallations finds Java installations on this machine and
+// probes each one for its version and architecture.
+//
+// Java is searched in JRE_HOME, JAVA_HOME, PATH, well-known installation
+// directories (like /usr/lib/jvm or /Library/Java/JavaVirtualMachines),
+// SDKMAN and IntelliJ IDEA (~/.jdks) directories and, on Windows, registry.
+// Installations are listed in that order, executables that fail to run are
+// skipped.
+func DiscoverJavaInstallations() ([]JavaInstallation, error) {
+	var res []JavaInstallation
+	seen := make(map[string]bool)
+	for _, path := range javaCandidates() {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		resolved, err := filepath.EvalSymlinks(path)
+		if err != nil || seen[resolved] {
+			continue
+		}
+		seen[resolved] = true
+
+		inst, err := probeJava(path)
+		if err != nil {
+			continue
+		}
+		res = append(res, *inst)
+	}
+	if len(res) == 0 {
+		return nil, errors.New("no Java installations found")
+	}
+	return res, nil
+}
+
+// javaCandidates returns paths java executable may be located at.
+func javaCandidates() []string {
+	javaBin := "java"
+	if runtime.GOOS == "windows" {
+		javaBin = "java.exe"
+	}
+
+	var homes []string
+	for _, env := range []string{"JRE_HOME", "JAVA_HOME"} {
+		if home := os.Getenv(env); home != "" {
+			homes = append(homes, home)
+		}
+	}
+
+	var paths []string
+	for _, home := range homes {
+		paths = append(paths, filepath.Join(home, "bin", javaBin))
+	}
+	if path, err := exec.LookPath(javaBin); err == nil {
+		paths = append(paths, path)
+	}
+
+	// Directories containing Java homes.
+	var parents []string
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)"} {
+			if dir := os.Getenv(env); dir != "" {
+				parents = append(parents,
+					filepath.Join(dir, "Java"),
+					filepath.Join(dir, "Eclipse Adoptium"),
+					filepath.Join(dir, "Zulu"),
+					filepath.Join(dir, "Microsoft"),
+				)
+			}
+		}
+	case "darwin":
+		// Homes are in <parent>/<jdk>/Contents/Home.
+		for _, parent := range []string{"/Library/Java/JavaVirtualMachines", filepath.Join(userHome(), "Library", "Java", "JavaVirtualMachines")} {
+			bundles, _ := filepath.Glob(filepath.Join(parent, "*", "Contents", "Home"))
+			for _, bundle := range bundles {
+				paths = append(paths, filepath.Join(bundle, "bin", javaBin))
+			}
+		}
+	default:
+		parents = append(parents, "/usr/lib/jvm", "/usr/lib64/jvm", "/usr/java", "/opt/java", "/opt/jdk")
+	}
+
+	sdkman := os.Getenv("SDKMAN_DIR")
+	if sdkman == "" {
+		sdkman = filepath.Join(userHome(), ".sdkman")
+	}
+	parents = append(parents, filepath.Join(sdkman, "candidates", "java"), filepath.Join(userHome(), ".jdks"))
+
+	for _, parent := range parents {
+		children, _ := filepath.Glob(filepath.Join(parent, "*"))
+		for _, home := range children {
+			paths = append(paths, filepath.Join(home, "bin", javaBin))
+		}
+	}
+
+	for _, home := range javaRegistryHomes() {
+		paths = append(paths, filepath.Join(home, "bin", javaBin))
+	}
+	return paths
+}
+
+func userHome() string {
+	if runtime.GOOS == "windows" {
+		return os.Getenv("USERPROFILE")
+	}
+	return os.Getenv("HOME")
+}
+
+// probeJava runs java executable to get its properties.
+func probeJava(path string) (*JavaInstallation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), javaProbeTimeout)
+	defer cancel()
+
+	// Properties are printed to stderr.
+	out, err := exec.CommandContext(ctx, path, "-XshowSettings:properties", "-version").CombinedOutput()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to run %s", path)
+	}
+
+	props := parseJavaProperties(out)
+	if props["java.version"] == "" {
+		return nil, errors.New("failed to get Java version from " + path)
+	}
+	return &JavaInstallation{
+		Path:         path,
+		Home:         props["java.home"],
+		Version:      props["java.version"],
+		MajorVersion: javaMajorVersion(props["java.version"]),
+		Vendor:       props["java.vendor"],
+		Arch:         props["os.arch"],
+		Is64Bit:      props["sun.arch.data.model"] == "64",
+	}, nil
+}
+
+// parseJavaProperties parses output of -XshowSettings:properties:
+//
+//	Property settings:
+//	    java.home = /usr/lib/jvm/java-17-openjdk-amd64
+//	    java.library.path = /usr/java/packages/lib
+//	        /usr/lib/x86_64-linux-gnu/jni
+//
+// Only first value of multi-value properties is returned.
+func parseJavaProperties(out []byte) map[string]string {
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "     ") {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSpace(line), " = ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		props[parts[0]] = parts[1]
+	}
+	return props
+}
+
+// javaMajorVersion returns feature version number from java.version value:
+// 8 for "1.8.0_382", 17 for "17.0.8".
+func javaMajorVersion(version string) int {
+	version = strings.TrimPrefix(version, "1.")
+	end := 0
+	for end < len(version) && version[end] >= '0' && version[end] <= '9' {
+		end++
+	}
+	major, _ := strconv.Atoi(version[:end])
+	return major
+}
+
+var (
+	discoveredJavaOnce sync.Once
+	discoveredJava     []JavaInstallation
+)
+
+// findJava returns java executable best matching required major version
+// (0 means unknown): installation with exactly that version or, if there is
+// none, the oldest newer one. 64-bit installations are preferred. If nothing
+// matches, findSystemJava is used.
+//
+// Installations are discovered only once per process.
+func findJava(majorVersion int) (string, error) {
+	if majorVersion == 0 {
+		return findSystemJava()
+	}
+	discoveredJavaOnce.Do(func() {
+		discoveredJava, _ = DiscoverJavaInstallations()
+	})
+
+	var best *JavaInstallation
+	for i := range discoveredJava {
+		inst := &discoveredJava[i]
+		if inst.MajorVersion < majorVersion {
+			continue
+		}
+		if best == nil || javaBetterMatch(inst, best, majorVersion) {
+			best = inst
+		}
+	}
+	if best == nil {
+		return findSystemJava()
+	}
+	return best.Path, nil
+}
+
+// javaBetterMatch reports whether a matches required major version better
+// than b. Both are expected to be not older than required.
+func javaBetterMatch(a, b *JavaInstallation, majorVersion int) bool {
+	aExact, bExact := a.MajorVersion == majorVersion, b.MajorVersion == majorVersion
+	if aExact != bExact {
+		return aExact
+	}
+	if a.MajorVersion != b.MajorVersion {
+		return a.MajorVersion < b.MajorVersion
+	}
+	return a.Is64Bit && !b.Is64Bit
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package gomine
+
+// javaRegistryHomes returns Java homes listed in Windows registry.
+func javaRegistryHomes() []string {
+	return nil
+}
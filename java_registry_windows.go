@@ -0,0 +1,43 @@
+//go:build windows
+// +build windows
+
+package gomine
+
+import "golang.org/x/sys/windows/registry"
+
+// javaRegistryKeys are registry keys (under HKEY_LOCAL_MACHINE) Oracle and
+// OpenJDK installers register Java homes under, one subkey per version.
+var javaRegistryKeys = []string{
+	`SOFTWARE\JavaSoft\Java Runtime Environment`,
+	`SOFTWARE\JavaSoft\Java Development Kit`,
+	`SOFTWARE\JavaSoft\JRE`,
+	`SOFTWARE\JavaSoft\JDK`,
+}
+
+// javaRegistryHomes returns Java homes listed in registry, both 64-bit and
+// 32-bit views are checked.
+func javaRegistryHomes() []string {
+	var homes []string
+	for _, view := range []uint32{registry.WOW64_64KEY, registry.WOW64_32KEY} {
+		for _, keyPath := range javaRegistryKeys {
+			key, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.READ|view)
+			if err != nil {
+				continue
+			}
+			versions, _ := key.ReadSubKeyNames(-1)
+			key.Close()
+
+			for _, version := range versions {
+				verKey, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath+`\`+version, registry.READ|view)
+				if err != nil {
+					continue
+				}
+				if home, _, err := verKey.GetStringValue("JavaHome"); err == nil && home != "" {
+					homes = append(homes, home)
+				}
+				verKey.Close()
+			}
+		}
+	}
+	return homes
+}
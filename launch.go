@@ -2,13 +2,20 @@ package gomine
 
 import (
 	"path/filepath"
-	"runtime"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/pkg/errors"
 )
 
+// BuildCommandLine returns java executable and arguments used to launch the
+// game.
+//
+// If prof.JVMPath is empty, Java matching v.JavaVersion is searched among
+// installations found on the machine. Java runtimes installed by
+// Root.UpdateVersion are not known here, use Root.BuildCommandLine to prefer
+// them.
 func (v *Version) BuildCommandLine(prof Profile, authData AuthData, versionsDir, libsDir, nativesDir, assetsDir string) (bin string, args []string, err error) {
 	gameDir, err := filepath.Abs(prof.GameDir)
 	if err != nil {
@@ -31,20 +38,31 @@ func (v *Version) BuildCommandLine(prof Profile, authData AuthData, versionsDir,
 		return "", nil, errors.Wrap(err, "failed to get abs path")
 	}
 
-	classPath, err := v.BuildClassPath(versionsDir, libsDir)
+	classPath, err := v.buildClassPath(versionsDir, libsDir, &prof)
 	if err != nil {
 		return "", nil, errors.Wrap(err, "failed to build classpath")
 	}
 
+	// Finding ${game_assets} requires reading assets index, skip it for
+	// versions that don't use it.
+	gameAssets := assetsDir
+	if argsContain(v.GameArgs, "${game_assets}") || argsContain(v.JVMArgs, "${game_assets}") ||
+		strings.Contains(prof.CustomGameArgs, "${game_assets}") || strings.Contains(prof.CustomJVMArgs, "${game_assets}") {
+		gameAssets = v.gameAssetsDir(assetsDir, gameDir)
+	}
+
 	argsReplacer := strings.NewReplacer(
 		"${natives_directory}", nativesDir,
 		"${launcher_name}", "gomine-framework",
 		"${launcher_version}", "0.1",
 		"${classpath}", classPath,
+		"${classpath_separator}", classPathSeparator(),
+		"${library_directory}", libsDir,
 		"${auth_player_name}", authData.PlayerName,
 		"${version_name}", v.ID,
 		"${game_directory}", gameDir,
 		"${assets_root}", assetsDir,
+		"${game_assets}", gameAssets,
 		"${assets_index_name}", v.AssetIndex.ID,
 		"${auth_uuid}", authData.UUID,
 		"${auth_access_token}", authData.Token,
@@ -52,39 +70,42 @@ func (v *Version) BuildCommandLine(prof Profile, authData AuthData, versionsDir,
 		"${version_type}", v.Type,
 		"${resolution_width}", strconv.Itoa(prof.ResolutionWidth),
 		"${resolution_height}", strconv.Itoa(prof.ResolutionHeight),
+		"${quickPlayPath}", prof.QuickPlayPath,
+		"${quickPlaySingleplayer}", prof.QuickPlaySingleplayer,
+		"${quickPlayMultiplayer}", prof.QuickPlayMultiplayer,
+		"${quickPlayRealms}", prof.QuickPlayRealms,
 	)
+	features := NewFeatures(&prof, &authData)
 
 	cmdLine := make([]string, 0, len(v.JVMArgs)+len(v.GameArgs)+10)
 
 	javaBin := prof.JVMPath
 	if javaBin == "" {
-		javaBin, err = findSystemJava()
+		javaBin, err = findJava(v.JavaVersion.MajorVersion)
 		if err != nil {
 			return "", nil, errors.Wrap(err, "failed to detect system java")
 		}
 	}
 
-	for _, arg := range v.JVMArgs {
-		if !EvaluateRules(arg.Rules, &prof) {
-			continue
-		}
+	customJVMArgs, err := splitArgs(prof.CustomJVMArgs)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "malformed custom JVM arguments")
+	}
+	customGameArgs, err := splitArgs(prof.CustomGameArgs)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "malformed custom game arguments")
+	}
 
-		// It's important to split "grouped" arguments like
-		// "-Da=1 -Db=2".
-		// But strings.Split can easily give us "", which is unacceptable and wouuld break
-		// command.
-		for _, part := range strings.Split(argsReplacer.Replace(arg.Value), " ") {
-			if part == "" {
-				continue
-			}
-			cmdLine = append(cmdLine, part)
-		}
+	jvmArgs := v.JVMArgs
+	if len(jvmArgs) == 0 {
+		jvmArgs = defaultJVMArgs
 	}
-	for _, part := range strings.Split(argsReplacer.Replace(prof.CustomJVMArgs), " ") {
-		if part == "" {
-			continue
-		}
-		cmdLine = append(cmdLine, part)
+	cmdLine = appendArgs(cmdLine, jvmArgs, &prof, features, argsReplacer)
+	if logConfig := v.logConfigPath(assetsDir); logConfig != "" {
+		cmdLine = append(cmdLine, strings.Replace(v.Logging.Client.Argument, "${path}", logConfig, -1))
+	}
+	for _, part := range customJVMArgs {
+		cmdLine = append(cmdLine, argsReplacer.Replace(part))
 	}
 	if prof.HeapMaxMB != 0 {
 		cmdLine = append(cmdLine, "-Xmx"+strconv.Itoa(prof.HeapMaxMB)+"M")
@@ -92,42 +113,117 @@ func (v *Version) BuildCommandLine(prof Profile, authData AuthData, versionsDir,
 
 	cmdLine = append(cmdLine, v.MainClass)
 
-	for _, arg := range v.GameArgs {
-		// It's important to split "grouped" arguments like
-		// "-Da=1 -Db=2".
-		// But strings.Split can easily give us "", which is unacceptable and wouuld break
-		// command.
-		for _, part := range strings.Split(argsReplacer.Replace(arg.Value), " ") {
-			if part == "" {
-				continue
+	cmdLine = appendArgs(cmdLine, v.GameArgs, &prof, features, argsReplacer)
+	for _, part := range customGameArgs {
+		cmdLine = append(cmdLine, argsReplacer.Replace(part))
+	}
+
+	return javaBin, cmdLine, nil
+}
+
+// argsContain reports whether any value of args contains s.
+func argsContain(args []Argument, s string) bool {
+	for _, arg := range args {
+		for _, value := range arg.Value {
+			if strings.Contains(value, s) {
+				return true
 			}
-			cmdLine = append(cmdLine, part)
 		}
 	}
-	for _, part := range strings.Split(argsReplacer.Replace(prof.CustomGameArgs), " ") {
-		if part == "" {
+	return false
+}
+
+// appendArgs appends values of arguments allowed by rules to cmdLine.
+//
+// Placeholders are substituted in each value separately, so substituted paths
+// or names containing spaces are still passed as single arguments.
+func appendArgs(cmdLine []string, args []Argument, prof *Profile, features Features, replacer *strings.Replacer) []string {
+	for _, arg := range args {
+		if !EvaluateRules(arg.Rules, prof, features) {
 			continue
 		}
-		cmdLine = append(cmdLine, part)
+		for _, value := range arg.Value {
+			// Legacy minecraftArguments string with double spaces gives
+			// us empty values.
+			if value == "" {
+				continue
+			}
+			cmdLine = append(cmdLine, replacer.Replace(value))
+		}
 	}
+	return cmdLine
+}
 
-	return javaBin, cmdLine, nil
+// splitArgs splits command line string into arguments the way POSIX shell
+// does: arguments are separated by whitespace which can be included into
+// them using single or double quotes.
+//
+// Backslash escapes next character only if it is a quote, backslash or
+// whitespace (or, inside double quotes, double quote or backslash), so
+// Windows paths can be used as is.
+func splitArgs(s string) ([]string, error) {
+	var (
+		args    []string
+		current []rune
+		inArg   bool
+		quote   rune
+	)
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+				continue
+			}
+			current = append(current, r)
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+				continue
+			}
+			if r == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+				i++
+				r = runes[i]
+			}
+			current = append(current, r)
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, string(current))
+				current = current[:0]
+				inArg = false
+			}
+		default:
+			if r == '\\' && i+1 < len(runes) && (strings.ContainsRune(`'"\`, runes[i+1]) || unicode.IsSpace(runes[i+1])) {
+				i++
+				r = runes[i]
+			}
+			current = append(current, r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inArg {
+		args = append(args, string(current))
+	}
+	return args, nil
 }
 
 func (v *Version) BuildClassPath(versionDir, libsDir string) (string, error) {
-	var pathSep string
-	if runtime.GOOS == "windows" {
-		pathSep = ";"
-	} else {
-		pathSep = ":"
-	}
+	return v.buildClassPath(versionDir, libsDir, nil)
+}
 
+// buildClassPath is BuildClassPath for platform prof is prepared for.
+func (v *Version) buildClassPath(versionDir, libsDir string, prof *Profile) (string, error) {
 	libs := make([]string, 0, len(v.Libraries)+1)
-	for _, lib := range v.Libraries {
-		if !lib.ShouldUse() {
-			continue
-		}
-
+	for _, lib := range v.activeLibraries(prof) {
 		path, err := lib.SavePath()
 		if err != nil {
 			return "", errors.Wrapf(err, "failed to get library path for %s", lib.Name)
@@ -135,7 +231,7 @@ func (v *Version) BuildClassPath(versionDir, libsDir string) (string, error) {
 
 		libs = append(libs, filepath.Join(libsDir, path))
 	}
-	libs = append(libs, filepath.Join(versionDir, v.ID, v.ID+".jar"))
+	libs = append(libs, filepath.Join(versionDir, v.jarID(), v.jarID()+".jar"))
 
-	return strings.Join(libs, pathSep), nil
+	return strings.Join(libs, classPathSeparator()), nil
 }
@@ -0,0 +1,79 @@
+package gomine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Coord is a Maven artifact coordinate in form
+// <group>:<artifact>:<version>[:<classifier>][@<extension>].
+type Coord struct {
+	Group      string
+	Artifact   string
+	Version    string
+	Classifier string
+
+	// Extension is the artifact file extension, "jar" if empty.
+	Extension string
+}
+
+// ParseCoord parses Maven coordinate string.
+func ParseCoord(s string) (Coord, error) {
+	c := Coord{}
+	if i := strings.LastIndex(s, "@"); i != -1 {
+		c.Extension = s[i+1:]
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 && len(parts) != 4 {
+		return Coord{}, errors.New("malformed maven coordinates: " + s)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return Coord{}, errors.New("malformed maven coordinates: " + s)
+		}
+	}
+	c.Group, c.Artifact, c.Version = parts[0], parts[1], parts[2]
+	if len(parts) == 4 {
+		c.Classifier = parts[3]
+	}
+	return c, nil
+}
+
+func (c Coord) String() string {
+	s := c.Group + ":" + c.Artifact + ":" + c.Version
+	if c.Classifier != "" {
+		s += ":" + c.Classifier
+	}
+	if c.Extension != "" {
+		s += "@" + c.Extension
+	}
+	return s
+}
+
+// WithClassifier returns copy of c with classifier replaced.
+func (c Coord) WithClassifier(classifier string) Coord {
+	c.Classifier = classifier
+	return c
+}
+
+// Path returns artifact path relative to repository root (or libraries
+// directory):
+// <group path>/<artifact>/<version>/<artifact>-<version>[-<classifier>].<extension>
+func (c Coord) Path() string {
+	fileName := c.Artifact + "-" + c.Version
+	if c.Classifier != "" {
+		fileName += "-" + c.Classifier
+	}
+	ext := c.Extension
+	if ext == "" {
+		ext = "jar"
+	}
+
+	groupPath := strings.Replace(c.Group, ".", string(os.PathSeparator), -1)
+	return filepath.Join(groupPath, c.Artifact, c.Version, fileName+"."+ext)
+}
@@ -2,8 +2,8 @@ package gomine
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"net/http"
 
 	"github.com/pkg/errors"
 )
@@ -30,8 +30,11 @@ type MojangAuth struct {
 	ClientID string
 
 	// URL that will be prepended ot each API endpoint path.
-	// Defaults to https://authserver.mojang.com.
+	// Takes precedence over Net.AuthURL.
 	AuthURL string
+
+	// Net configures HTTP client and auth server URL.
+	Net NetConfig
 }
 
 type mojangProfile struct {
@@ -68,8 +71,12 @@ type mojangAuthResp struct {
 }
 
 func (ma MojangAuth) Login(user, pass string) (AuthData, error) {
+	return ma.LoginContext(context.Background(), user, pass)
+}
+
+func (ma MojangAuth) LoginContext(ctx context.Context, user, pass string) (AuthData, error) {
 	if ma.AuthURL == "" {
-		ma.AuthURL = "https://authserver.mojang.com"
+		ma.AuthURL = ma.Net.authURL()
 	}
 
 	req := map[string]interface{}{
@@ -88,7 +95,7 @@ func (ma MojangAuth) Login(user, pass string) (AuthData, error) {
 		return AuthData{}, errors.Wrap(err, "failed to encode auth request")
 	}
 
-	resp, err := http.Post(ma.AuthURL+"/authenticate", "application/json", bytes.NewReader(blob))
+	resp, err := ma.Net.post(ctx, ma.AuthURL+"/authenticate", "application/json", bytes.NewReader(blob))
 	if err != nil {
 		return AuthData{}, errors.Wrap(err, "failed to send auth request")
 	}
@@ -112,6 +119,7 @@ func (ma MojangAuth) Login(user, pass string) (AuthData, error) {
 			PlayerName: user,
 			UUID: "DEMO-USER",
 			Token: "DEMO-USER",
+			Demo: true,
 		}, errors.New("not premium")
 	}
 
@@ -124,8 +132,12 @@ func (ma MojangAuth) Login(user, pass string) (AuthData, error) {
 }
 
 func (ma MojangAuth) Refresh(ad *AuthData) error {
+	return ma.RefreshContext(context.Background(), ad)
+}
+
+func (ma MojangAuth) RefreshContext(ctx context.Context, ad *AuthData) error {
 	if ma.AuthURL == "" {
-		ma.AuthURL = "https://authserver.mojang.com"
+		ma.AuthURL = ma.Net.authURL()
 	}
 
 	req := map[string]interface{}{
@@ -143,7 +155,7 @@ func (ma MojangAuth) Refresh(ad *AuthData) error {
 		return errors.Wrap(err, "failed to encode refresh request")
 	}
 
-	resp, err := http.Post(ma.AuthURL+"/refresh", "application/json", bytes.NewReader(blob))
+	resp, err := ma.Net.post(ctx, ma.AuthURL+"/refresh", "application/json", bytes.NewReader(blob))
 	if err != nil {
 		return errors.Wrap(err, "failed to send refresh request")
 	}
@@ -168,8 +180,12 @@ func (ma MojangAuth) Refresh(ad *AuthData) error {
 }
 
 func (ma MojangAuth) Validate(ad AuthData) (bool, error) {
+	return ma.ValidateContext(context.Background(), ad)
+}
+
+func (ma MojangAuth) ValidateContext(ctx context.Context, ad AuthData) (bool, error) {
 	if ma.AuthURL == "" {
-		ma.AuthURL = "https://authserver.mojang.com"
+		ma.AuthURL = ma.Net.authURL()
 	}
 
 	req := map[string]interface{}{
@@ -182,7 +198,7 @@ func (ma MojangAuth) Validate(ad AuthData) (bool, error) {
 		return false, errors.Wrap(err, "failed to encode validate request")
 	}
 
-	resp, err := http.Post(ma.AuthURL+"/validate", "application/json", bytes.NewReader(blob))
+	resp, err := ma.Net.post(ctx, ma.AuthURL+"/validate", "application/json", bytes.NewReader(blob))
 	if err != nil {
 		return false, errors.Wrap(err, "failed to send validate request")
 	}
@@ -204,8 +220,12 @@ func (ma MojangAuth) Validate(ad AuthData) (bool, error) {
 }
 
 func (ma MojangAuth) Invalidate(ad AuthData) error {
+	return ma.InvalidateContext(context.Background(), ad)
+}
+
+func (ma MojangAuth) InvalidateContext(ctx context.Context, ad AuthData) error {
 	if ma.AuthURL == "" {
-		ma.AuthURL = "https://authserver.mojang.com"
+		ma.AuthURL = ma.Net.authURL()
 	}
 
 	req := map[string]interface{}{
@@ -218,7 +238,7 @@ func (ma MojangAuth) Invalidate(ad AuthData) error {
 		return errors.Wrap(err, "failed to encode invalidate request")
 	}
 
-	resp, err := http.Post(ma.AuthURL+"/invalidate", "application/json", bytes.NewReader(blob))
+	resp, err := ma.Net.post(ctx, ma.AuthURL+"/invalidate", "application/json", bytes.NewReader(blob))
 	if err != nil {
 		return errors.Wrap(err, "failed to send invalidate request")
 	}
@@ -0,0 +1,112 @@
+package gomine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+)
+
+// writeMojangJSON writes v the same way as Python's json.dumps(v,
+// sort_keys=True) does, which is the format of files published by Mojang:
+// keys are sorted, items are separated by ", ", keys from values by ": " and
+// all non-ASCII characters are escaped.
+//
+// v should consist of map[string]interface{}, []interface{}, strings, bools,
+// integers, json.Number and json.RawMessage values.
+func writeMojangJSON(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case string:
+		writeMojangString(buf, v)
+	case int:
+		buf.WriteString(strconv.Itoa(v))
+	case uint:
+		buf.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint64:
+		buf.WriteString(strconv.FormatUint(v, 10))
+	case json.Number:
+		buf.WriteString(v.String())
+	case json.RawMessage:
+		dec := json.NewDecoder(bytes.NewReader(v))
+		dec.UseNumber()
+		var val interface{}
+		if err := dec.Decode(&val); err != nil {
+			return err
+		}
+		return writeMojangJSON(buf, val)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+			if err := writeMojangJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+			writeMojangString(buf, key)
+			buf.WriteString(": ")
+			if err := writeMojangJSON(buf, v[key]); err != nil {
+				return errors.Wrap(err, key)
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return errors.Errorf("unsupported JSON value type %T", v)
+	}
+	return nil
+}
+
+func writeMojangString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		default:
+			switch {
+			case r >= ' ' && r <= '~':
+				buf.WriteRune(r)
+			case r > 0xffff:
+				r1, r2 := utf16.EncodeRune(r)
+				fmt.Fprintf(buf, `\u%04x\u%04x`, r1, r2)
+			default:
+				fmt.Fprintf(buf, `\u%04x`, r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
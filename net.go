@@ -0,0 +1,235 @@
+package gomine
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultManifestURL  = "https://launchermeta.mojang.com/mc/game/version_manifest.json"
+	DefaultAssetsURL    = "http://resources.download.minecraft.net/"
+	DefaultLibrariesURL = "https://libraries.minecraft.net/"
+	DefaultAuthURL      = "https://authserver.mojang.com"
+
+	DefaultFabricMetaURL = "https://meta.fabricmc.net"
+	DefaultQuiltMetaURL  = "https://meta.quiltmc.org"
+
+	DefaultJavaRuntimesURL = "https://launchermeta.mojang.com/v1/products/java-runtime/2ec0cc96c44e5a76b9c8b7c39df7210883d12871/all.json"
+)
+
+// NetConfig controls how network resources are accessed.
+//
+// Zero value (and nil pointer) uses http.DefaultClient and official Mojang
+// servers.
+type NetConfig struct {
+	// Client is used for all requests. Defaults to http.DefaultClient.
+	Client *http.Client
+
+	// UserAgent is sent in User-Agent header of all requests, if set.
+	UserAgent string
+
+	// ManifestURL is the URL of versions manifest. Defaults to
+	// DefaultManifestURL.
+	ManifestURL string
+
+	// AssetsURL replaces DefaultAssetsURL in asset objects URLs.
+	AssetsURL string
+
+	// LibrariesURL replaces DefaultLibrariesURL in libraries URLs.
+	LibrariesURL string
+
+	// AuthURL is prepended to each authentication API endpoint path.
+	// Defaults to DefaultAuthURL.
+	AuthURL string
+
+	// FabricMetaURL is the base URL of Fabric meta API. Defaults to
+	// DefaultFabricMetaURL.
+	FabricMetaURL string
+
+	// QuiltMetaURL is the base URL of Quilt meta API. Defaults to
+	// DefaultQuiltMetaURL.
+	QuiltMetaURL string
+
+	// JavaRuntimesURL is the URL of Java runtimes manifest. Defaults to
+	// DefaultJavaRuntimesURL.
+	JavaRuntimesURL string
+
+	// Mirrors are tried in order before official servers when downloading
+	// files and versions manifest.
+	Mirrors []Mirror
+}
+
+// OfficialSource is the source name reported for files downloaded from
+// official servers (or ones set in NetConfig).
+const OfficialSource = "official"
+
+// Mirror is an alternative source of Mojang files.
+type Mirror struct {
+	Name string
+
+	// Rewrites maps URL prefixes of official servers to corresponding
+	// mirror URLs. If multiple prefixes match, the longest one is used.
+	Rewrites map[string]string
+}
+
+// BMCLAPI mirrors launcher metadata, client jars, libraries and assets.
+var BMCLAPI = Mirror{
+	Name: "bmclapi",
+	Rewrites: map[string]string{
+		"https://launchermeta.mojang.com/":         "https://bmclapi2.bangbang93.com/",
+		"https://launcher.mojang.com/":             "https://bmclapi2.bangbang93.com/",
+		"https://piston-meta.mojang.com/":          "https://bmclapi2.bangbang93.com/",
+		"https://piston-data.mojang.com/":          "https://bmclapi2.bangbang93.com/",
+		"https://libraries.minecraft.net/":         "https://bmclapi2.bangbang93.com/maven/",
+		"http://resources.download.minecraft.net/": "https://bmclapi2.bangbang93.com/assets/",
+	},
+}
+
+// Rewrite returns mirror URL for url or empty string if mirror doesn't
+// serve it.
+func (m *Mirror) Rewrite(url string) string {
+	longest := ""
+	for prefix := range m.Rewrites {
+		if strings.HasPrefix(url, prefix) && len(prefix) > len(longest) {
+			longest = prefix
+		}
+	}
+	if longest == "" {
+		return ""
+	}
+	return m.Rewrites[longest] + strings.TrimPrefix(url, longest)
+}
+
+// source is a single location from which resource can be downloaded.
+type source struct {
+	Name string
+	URL  string
+}
+
+// sources returns list of locations to try for url, mirrors first.
+func (nc *NetConfig) sources(url string) []source {
+	if nc == nil {
+		return []source{{Name: OfficialSource, URL: url}}
+	}
+
+	res := make([]source, 0, len(nc.Mirrors)+1)
+	for i := range nc.Mirrors {
+		if mirrorURL := nc.Mirrors[i].Rewrite(url); mirrorURL != "" {
+			res = append(res, source{Name: nc.Mirrors[i].Name, URL: mirrorURL})
+		}
+	}
+	return append(res, source{Name: OfficialSource, URL: nc.resolve(url)})
+}
+
+func (nc *NetConfig) client() *http.Client {
+	if nc == nil || nc.Client == nil {
+		return http.DefaultClient
+	}
+	return nc.Client
+}
+
+func (nc *NetConfig) manifestURL() string {
+	if nc == nil || nc.ManifestURL == "" {
+		return DefaultManifestURL
+	}
+	return nc.ManifestURL
+}
+
+func (nc *NetConfig) authURL() string {
+	if nc == nil || nc.AuthURL == "" {
+		return DefaultAuthURL
+	}
+	return strings.TrimSuffix(nc.AuthURL, "/")
+}
+
+func (nc *NetConfig) fabricMetaURL() string {
+	if nc == nil || nc.FabricMetaURL == "" {
+		return DefaultFabricMetaURL
+	}
+	return strings.TrimSuffix(nc.FabricMetaURL, "/")
+}
+
+func (nc *NetConfig) quiltMetaURL() string {
+	if nc == nil || nc.QuiltMetaURL == "" {
+		return DefaultQuiltMetaURL
+	}
+	return strings.TrimSuffix(nc.QuiltMetaURL, "/")
+}
+
+func (nc *NetConfig) javaRuntimesURL() string {
+	if nc == nil || nc.JavaRuntimesURL == "" {
+		return DefaultJavaRuntimesURL
+	}
+	return nc.JavaRuntimesURL
+}
+
+// resolve replaces default base URLs in url with overridden ones.
+func (nc *NetConfig) resolve(url string) string {
+	if nc == nil {
+		return url
+	}
+	if nc.AssetsURL != "" && strings.HasPrefix(url, DefaultAssetsURL) {
+		return strings.TrimSuffix(nc.AssetsURL, "/") + "/" + strings.TrimPrefix(url, DefaultAssetsURL)
+	}
+	if nc.LibrariesURL != "" && strings.HasPrefix(url, DefaultLibrariesURL) {
+		return strings.TrimSuffix(nc.LibrariesURL, "/") + "/" + strings.TrimPrefix(url, DefaultLibrariesURL)
+	}
+	return url
+}
+
+func (nc *NetConfig) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if nc != nil && nc.UserAgent != "" {
+		req.Header.Set("User-Agent", nc.UserAgent)
+	}
+	return nc.client().Do(req.WithContext(ctx))
+}
+
+// get requests url from each of its sources until one of them returns
+// HTTP 200. Name of the source is returned along with response.
+func (nc *NetConfig) get(ctx context.Context, url string) (*http.Response, string, error) {
+	var lastErr error
+	for _, src := range nc.sources(url) {
+		resp, err := nc.getURL(ctx, src.URL, 0)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, "", ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode != 200 {
+			resp.Body.Close()
+			lastErr = errors.New("HTTP " + resp.Status)
+			continue
+		}
+		return resp, src.Name, nil
+	}
+	return nil, "", lastErr
+}
+
+// getURL requests url as is, without trying mirrors. If offset is not zero,
+// only part of resource starting at offset is requested.
+func (nc *NetConfig) getURL(ctx context.Context, url string, offset int64) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset != 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+	return nc.do(ctx, req)
+}
+
+func (nc *NetConfig) post(ctx context.Context, url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return nc.do(ctx, req)
+}
@@ -0,0 +1,322 @@
+package gomine
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// GameEvent is the game process lifecycle event.
+type GameEvent int
+
+const (
+	// GameStarting is sent before game process is started.
+	GameStarting GameEvent = iota
+
+	// GameWindowReady is sent when game log indicates that window is
+	// created. Detection is heuristic, event may be not sent by some
+	// versions.
+	GameWindowReady
+
+	// GameExited is sent when game exits with zero status or after it was
+	// stopped using Stop, Kill or by cancelling context.
+	GameExited
+
+	// GameCrashed is sent when game exits with non-zero status.
+	GameCrashed
+)
+
+func (e GameEvent) String() string {
+	switch e {
+	case GameStarting:
+		return "starting"
+	case GameWindowReady:
+		return "window ready"
+	case GameExited:
+		return "exited"
+	case GameCrashed:
+		return "crashed"
+	}
+	return "unknown"
+}
+
+// windowReadyMarkers are substrings of log lines printed by game right when
+// its window is created.
+var windowReadyMarkers = []string{
+	"Backend library: LWJGL", // 1.13+
+	"LWJGL Version: ",        // older versions
+}
+
+// GameProcess is the handle of running game instance.
+type GameProcess struct {
+	cmd       *exec.Cmd
+	ctx       context.Context
+	gameDir   string
+	startTime time.Time
+
+	events      chan GameEvent
+	windowReady sync.Once
+	stopping    int32
+
+	// Game stdout is parsed into log records, formatted ones are written
+	// to stdout.
+	stdout      io.Writer
+	stdoutLines *lineWriter
+	log4j       log4jParser
+	logHandler  func(*GameProcess, LogRecord)
+
+	// Set before done is closed.
+	done     chan struct{}
+	err      error
+	exitTime time.Time
+}
+
+// BuildCommandLine is Version.BuildCommandLine using directories and
+// AuthData of r. If prof.JVMPath is empty, Java runtime installed by
+// UpdateVersion for version is used if there is one.
+func (r *Root) BuildCommandLine(ver *Version, prof *Profile) (bin string, args []string, err error) {
+	launchProf := *prof
+	if launchProf.JVMPath == "" {
+		launchProf.JVMPath = r.installedJavaRuntime(ver)
+	}
+
+	nativesDir := filepath.Join(r.VersionsDir(), ver.ID, "natives")
+	return ver.BuildCommandLine(launchProf, r.AuthData, r.VersionsDir(), r.LibrariesDir(), nativesDir, r.AssetsDir())
+}
+
+func (r *Root) StartVersion(ver *Version, prof *Profile, logRedirect io.Writer) (*GameProcess, error) {
+	return r.StartVersionContext(context.Background(), ver, prof, logRedirect)
+}
+
+// StartVersionContext starts the game and returns without waiting for it to
+// exit. Game is killed when ctx is cancelled.
+//
+// If prof.JVMPath is empty, Java runtime installed by UpdateVersion for
+// version is used if there is one.
+//
+// Game output is copied to stdout/stderr of current process and to
+// logRedirect if it is not nil. log4j events are converted to plain text
+// first and passed to Root.LogHandler.
+func (r *Root) StartVersionContext(ctx context.Context, ver *Version, prof *Profile, logRedirect io.Writer) (*GameProcess, error) {
+	if err := ver.CopyLegacyAssets(r.AssetsDir(), prof.GameDir); err != nil {
+		return nil, err
+	}
+
+	bin, args, err := r.BuildCommandLine(ver, prof)
+	if err != nil {
+		return nil, err
+	}
+
+	// Game writes crash reports and JVM writes its error logs to working
+	// directory.
+	if err := os.MkdirAll(prof.GameDir, os.ModePerm); err != nil {
+		return nil, errors.Wrap(err, "failed to create game directory")
+	}
+
+	p := &GameProcess{
+		cmd:     exec.CommandContext(ctx, bin, args...),
+		ctx:     ctx,
+		gameDir: prof.GameDir,
+		// Each event is sent at most once, so sending never blocks.
+		events:     make(chan GameEvent, 3),
+		done:       make(chan struct{}),
+		logHandler: r.LogHandler,
+	}
+	p.stdout = os.Stdout
+	if logRedirect != nil {
+		p.stdout = io.MultiWriter(os.Stdout, logRedirect)
+	}
+	p.cmd.Dir = prof.GameDir
+	p.stdoutLines = &lineWriter{line: p.handleLine}
+	p.cmd.Stdout = p.stdoutLines
+	p.cmd.Stderr = p.outputWriter(os.Stderr, logRedirect)
+
+	p.events <- GameStarting
+	if err := p.cmd.Start(); err != nil {
+		return nil, err
+	}
+	p.startTime = time.Now()
+	go p.wait()
+	return p, nil
+}
+
+func (p *GameProcess) outputWriter(std, logRedirect io.Writer) io.Writer {
+	writers := []io.Writer{std, &lineWriter{line: p.watchLog}}
+	if logRedirect != nil {
+		writers = append(writers, logRedirect)
+	}
+	return io.MultiWriter(writers...)
+}
+
+func (p *GameProcess) handleLine(line string) {
+	if rec, ok := p.log4j.line(line); ok {
+		p.handleRecord(rec)
+	}
+}
+
+func (p *GameProcess) handleRecord(rec LogRecord) {
+	p.watchLog(rec.Message)
+	io.WriteString(p.stdout, rec.String()+"\n")
+	if p.logHandler != nil {
+		p.logHandler(p, rec)
+	}
+}
+
+func (p *GameProcess) watchLog(line string) {
+	for _, marker := range windowReadyMarkers {
+		if strings.Contains(line, marker) {
+			p.windowReady.Do(func() {
+				p.events <- GameWindowReady
+			})
+			return
+		}
+	}
+}
+
+func (p *GameProcess) wait() {
+	err := p.cmd.Wait()
+	p.exitTime = time.Now()
+	p.err = err
+
+	p.stdoutLines.flush()
+	if rec, ok := p.log4j.flush(); ok {
+		p.handleRecord(rec)
+	}
+
+	if err != nil && atomic.LoadInt32(&p.stopping) == 0 && p.ctx.Err() == nil {
+		exitCode := -1
+		if p.cmd.ProcessState != nil {
+			exitCode = p.cmd.ProcessState.ExitCode()
+		}
+		p.err = collectCrashReport(p.gameDir, p.startTime, exitCode, err)
+		p.events <- GameCrashed
+	} else {
+		p.events <- GameExited
+	}
+	close(p.events)
+	close(p.done)
+}
+
+// Events returns channel lifecycle events are sent to. It is closed after
+// GameExited or GameCrashed event.
+func (p *GameProcess) Events() <-chan GameEvent {
+	return p.events
+}
+
+// PID returns game process ID.
+func (p *GameProcess) PID() int {
+	return p.cmd.Process.Pid
+}
+
+// Wait waits for game to exit. Returned error is nil if game exited with
+// zero status and *CrashReport otherwise. If game was stopped using Stop,
+// Kill or by cancelling context, *exec.ExitError is returned instead.
+func (p *GameProcess) Wait() error {
+	<-p.done
+	return p.err
+}
+
+// Exited reports whether game process has exited.
+func (p *GameProcess) Exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// ExitCode returns game exit code. -1 is returned if game is still running
+// or was terminated by signal.
+func (p *GameProcess) ExitCode() int {
+	if !p.Exited() || p.cmd.ProcessState == nil {
+		return -1
+	}
+	return p.cmd.ProcessState.ExitCode()
+}
+
+// StartTime returns time game process was started at.
+func (p *GameProcess) StartTime() time.Time {
+	return p.startTime
+}
+
+// ExitTime returns time game process exited at or zero time if it is still
+// running.
+func (p *GameProcess) ExitTime() time.Time {
+	if !p.Exited() {
+		return time.Time{}
+	}
+	return p.exitTime
+}
+
+// Kill kills game process immediately, without waiting for it to exit.
+func (p *GameProcess) Kill() error {
+	atomic.StoreInt32(&p.stopping, 1)
+	if err := p.cmd.Process.Kill(); err != nil && err != os.ErrProcessDone && !p.Exited() {
+		return err
+	}
+	return nil
+}
+
+// Stop asks game to exit (using SIGTERM on Unix and WM_CLOSE on Windows) and
+// waits for it. If game doesn't exit within timeout, it is killed. Stop
+// returns after game output is fully processed, so if game started child
+// processes that keep its output open, Stop waits for them too.
+func (p *GameProcess) Stop(timeout time.Duration) error {
+	atomic.StoreInt32(&p.stopping, 1)
+	if err := terminateProcess(p.cmd.Process); err != nil {
+		return p.killAndWait()
+	}
+
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(timeout):
+		return p.killAndWait()
+	}
+}
+
+// killAndWait kills game process and waits until it is reaped.
+func (p *GameProcess) killAndWait() error {
+	if err := p.Kill(); err != nil {
+		return err
+	}
+	<-p.done
+	return nil
+}
+
+// lineWriter calls line for each complete line written to it.
+type lineWriter struct {
+	line func(string)
+	buf  []byte
+}
+
+func (lw *lineWriter) Write(b []byte) (int, error) {
+	lw.buf = append(lw.buf, b...)
+	for {
+		i := bytes.IndexByte(lw.buf, '\n')
+		if i == -1 {
+			break
+		}
+		lw.line(strings.TrimSuffix(string(lw.buf[:i]), "\r"))
+		lw.buf = lw.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// flush passes incomplete last line to line function.
+func (lw *lineWriter) flush() {
+	if len(lw.buf) != 0 {
+		lw.line(strings.TrimSuffix(string(lw.buf), "\r"))
+		lw.buf = nil
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package gomine
+
+import (
+	"os"
+	"syscall"
+)
+
+// terminateProcess asks process to exit.
+func terminateProcess(p *os.Process) error {
+	return p.Signal(syscall.SIGTERM)
+}
@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package gomine
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// terminateProcess asks process to exit. taskkill without /F sends WM_CLOSE
+// to process windows.
+func terminateProcess(p *os.Process) error {
+	return exec.Command("taskkill", "/PID", strconv.Itoa(p.Pid)).Run()
+}
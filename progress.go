@@ -0,0 +1,52 @@
+package gomine
+
+// Progress receives updates about files being downloaded.
+//
+// Methods can be called concurrently from multiple goroutines.
+type Progress interface {
+	// Planned is called once before download starts with total number of
+	// bytes that may be transferred.
+	Planned(bytes uint64)
+
+	// Downloaded is called periodically while file is being downloaded
+	// with number of bytes received since previous call.
+	Downloaded(path string, bytes uint64)
+
+	// Skipped is called for files that already exist and have
+	// matching hash and for files listed in batch more than once.
+	Skipped(path string, size uint64)
+
+	// Done is called when file is successfully downloaded and verified.
+	// source is the name of Mirror that served the file or
+	// OfficialSource.
+	Done(path, source string)
+
+	// Failed is called when file download fails.
+	Failed(path string, err error)
+}
+
+// progressWriter reports bytes written through it as downloaded.
+//
+// Bytes of file already reported by previous attempts (*reported) are not
+// reported again, so restarted download is not counted twice.
+type progressWriter struct {
+	path     string
+	progress Progress
+
+	// pos is the offset of the next written byte in file.
+	pos      int64
+	reported *int64
+}
+
+func (pw *progressWriter) Write(b []byte) (int, error) {
+	start := pw.pos
+	pw.pos += int64(len(b))
+	if start < *pw.reported {
+		start = *pw.reported
+	}
+	if pw.pos > start {
+		pw.progress.Downloaded(pw.path, uint64(pw.pos-start))
+		*pw.reported = pw.pos
+	}
+	return len(b), nil
+}
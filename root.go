@@ -1,23 +1,39 @@
 package gomine
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"io/ioutil"
-	"net/http"
+	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 
 	"github.com/pkg/errors"
 )
 
-const versionsUrl = `https://launchermeta.mojang.com/mc/game/version_manifest.json`
-
 type Root struct {
 	LauncherDir string
 	AuthData    AuthData
 
+	// Net configures HTTP client and servers used to download versions.
+	Net NetConfig
+
+	// Offline disables all network access. Versions list is then built
+	// from cached versions manifest and installed versions only.
+	//
+	// Even if Offline is not set, cached manifest is used when remote one
+	// can't be downloaded.
+	Offline bool
+
+	// Downloader is used to fetch version files in UpdateVersion. If
+	// Downloader.Net is nil, Net from Root is used.
+	Downloader Downloader
+
+	// LogHandler, if set, receives game log records of processes started
+	// using StartVersion or RunVersion.
+	LogHandler func(p *GameProcess, rec LogRecord)
+
 	LatestRelease  string
 	LatestSnapshot string
 	knownVersions  map[string]VersionMeta
@@ -49,8 +65,23 @@ func (r *Root) LibrariesDir() string {
 }
 
 func (r *Root) GetVersion(id string) (*Version, error) {
+	return r.GetVersionContext(context.Background(), id)
+}
+
+// GetVersionContext returns information about version, downloading it if
+// necessary.
+//
+// If version inherits from another one (as modloader profiles do), parent
+// versions are resolved and merged into returned Version.
+func (r *Root) GetVersionContext(ctx context.Context, id string) (*Version, error) {
+	return r.getVersion(ctx, id, nil)
+}
+
+// getVersion is GetVersionContext that tracks chain of child versions being
+// resolved to detect inheritance loops.
+func (r *Root) getVersion(ctx context.Context, id string, children map[string]bool) (*Version, error) {
 	if r.knownVersions == nil {
-		if _, err := r.Versions(); err != nil {
+		if _, err := r.VersionsContext(ctx); err != nil {
 			return nil, err
 		}
 	}
@@ -68,19 +99,19 @@ func (r *Root) GetVersion(id string) (*Version, error) {
 		if meta.URL == "" {
 			return nil, errors.New("update: can't download local-only version")
 		}
+		if r.Offline {
+			return nil, errors.New("update: version is not installed and offline mode is enabled")
+		}
 
-		resp, err := http.Get(meta.URL)
+		resp, _, err := r.Net.get(ctx, meta.URL)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to download version info")
 		}
-		if resp.StatusCode != 200 {
-			return nil, errors.New("update: HTTP " + resp.Status)
-		}
+		defer resp.Body.Close()
 		blob, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to download version info")
 		}
-		resp.Body.Close()
 
 		versionDir := filepath.Join(r.VersionsDir(), meta.ID)
 		if err := os.MkdirAll(versionDir, os.ModePerm); err != nil {
@@ -90,6 +121,8 @@ func (r *Root) GetVersion(id string) (*Version, error) {
 		if err := ioutil.WriteFile(filepath.Join(versionDir, meta.ID+".json"), blob, os.ModePerm); err != nil {
 			return nil, errors.Wrap(err, "failed to write version info")
 		}
+		meta.Installed = true
+		r.knownVersions[meta.ID] = meta
 
 		if versionInfo, err = ReadVersionJSON(blob); err != nil {
 			return nil, errors.Wrap(err, "failed to parse version info")
@@ -106,50 +139,129 @@ func (r *Root) GetVersion(id string) (*Version, error) {
 		}
 	}
 
+	if versionInfo.InheritsFrom != "" {
+		if children == nil {
+			children = make(map[string]bool)
+		}
+		children[meta.ID] = true
+		if children[versionInfo.InheritsFrom] {
+			return nil, errors.New("update: version inheritance loop at " + versionInfo.InheritsFrom)
+		}
+
+		parent, err := r.getVersion(ctx, versionInfo.InheritsFrom, children)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get parent version %s", versionInfo.InheritsFrom)
+		}
+		versionInfo = inheritVersion(parent, versionInfo)
+	}
+
 	r.versions[meta.ID] = *versionInfo
 	return versionInfo, nil
 }
 
 func (r *Root) UpdateVersion(ver *Version) error {
-	if err := ver.DownloadLibraries(r.LibrariesDir()); err != nil {
+	return r.UpdateVersionContext(context.Background(), ver)
+}
+
+// UpdateVersionContext is like UpdateVersion but aborts all downloads when ctx
+// is cancelled.
+func (r *Root) UpdateVersionContext(ctx context.Context, ver *Version) error {
+	return r.UpdateVersionForProfile(ctx, ver, nil)
+}
+
+// UpdateVersionForProfile is like UpdateVersionContext but downloads libraries,
+// natives and Java runtime for platform prof is prepared for (see
+// Profile.Arch) instead of current one. Java runtime is not downloaded if
+// prof.JVMPath is set. Failure to get Java runtime manifest is only logged,
+// system Java is used then.
+func (r *Root) UpdateVersionForProfile(ctx context.Context, ver *Version, prof *Profile) error {
+	d := r.downloader()
+	indexJob := ver.assetIndexJob(r.AssetsDir())
+	jobs, err := ver.libraryJobs(r.LibrariesDir(), prof)
+	if err != nil {
 		return err
 	}
-	if err := ver.DownloadAssetsIndex(r.AssetsDir()); err != nil {
+	jobs = append(jobs, ver.clientJob(r.VersionsDir()))
+	jobs = append(jobs, ver.logConfigJobs(r.AssetsDir())...)
+
+	// Java runtime required by version is installed unless other Java is
+	// configured explicitly.
+	var javaRuntime *javaRuntimeInstall
+	if ver.JavaVersion.Component != "" && (prof == nil || prof.JVMPath == "") && !r.Offline {
+		javaRuntime, err = r.planJavaRuntime(ctx, ver.JavaVersion.Component, prof.arch())
+		switch {
+		case err == ErrNoJavaRuntime:
+			log.Println("No Java runtime", ver.JavaVersion.Component, "for this platform, system Java will be used")
+		case err != nil:
+			// Game files are still usable with system Java.
+			log.Println("Failed to prepare Java runtime", ver.JavaVersion.Component+", system Java will be used:", err)
+		default:
+			jobs = append(jobs, javaRuntime.jobs...)
+		}
+	}
+
+	if d.Progress != nil {
+		// Asset objects are not known until index is downloaded, use
+		// total size from version info instead.
+		d.Progress.Planned(plannedSize(jobs) + indexJob.Size + ver.AssetIndex.TotalSize)
+	}
+
+	// List of asset objects is known only after index is downloaded.
+	if err := d.run(ctx, []downloadJob{indexJob}); err != nil {
 		return err
 	}
-	if err := ver.DownloadAssets(r.AssetsDir()); err != nil {
+	assetJobs, err := ver.assetJobs(r.AssetsDir())
+	if err != nil {
 		return err
 	}
-	if err := ver.DownloadClient(r.VersionsDir()); err != nil {
+	jobs = append(jobs, assetJobs...)
+
+	if err := d.run(ctx, jobs); err != nil {
 		return err
 	}
-	if err := ver.ExtractNatives(r.LibrariesDir(), filepath.Join(r.VersionsDir(), ver.ID, "natives")); err != nil {
+	if javaRuntime != nil {
+		if err := javaRuntime.finish(); err != nil {
+			return err
+		}
+	}
+	if err := ver.CopyLegacyAssets(r.AssetsDir(), ""); err != nil {
+		return err
+	}
+	if err := ver.extractNatives(r.LibrariesDir(), filepath.Join(r.VersionsDir(), ver.ID, "natives"), prof); err != nil {
 		return err
 	}
 	return nil
 }
 
+func (r *Root) downloader() *Downloader {
+	d := r.Downloader
+	if d.Net == nil {
+		d.Net = &r.Net
+	}
+	return &d
+}
+
 func (r *Root) RunVersion(ver *Version, prof *Profile, logRedirect io.Writer) error {
-	nativesDir := filepath.Join(r.VersionsDir(), ver.ID, "natives")
-	bin, args, err := ver.BuildCommandLine(*prof, r.AuthData, r.VersionsDir(), r.LibrariesDir(), nativesDir, r.AssetsDir())
+	return r.RunVersionContext(context.Background(), ver, prof, logRedirect)
+}
+
+// RunVersionContext is like RunVersion but kills game process when ctx is
+// cancelled.
+func (r *Root) RunVersionContext(ctx context.Context, ver *Version, prof *Profile, logRedirect io.Writer) error {
+	p, err := r.StartVersionContext(ctx, ver, prof, logRedirect)
 	if err != nil {
 		return err
 	}
-	//log.Println("Command line:", bin, args)
-	cmd := exec.Command(bin, args...)
-	if logRedirect == nil {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	} else {
-		cmd.Stdout = io.MultiWriter(os.Stdout, logRedirect)
-		cmd.Stderr = io.MultiWriter(os.Stderr, logRedirect)
-	}
-	return cmd.Run()
+	return p.Wait()
 }
 
 func (r *Root) Versions() (map[string]VersionMeta, error) {
+	return r.VersionsContext(context.Background())
+}
+
+func (r *Root) VersionsContext(ctx context.Context) (map[string]VersionMeta, error) {
 	versions := make(map[string]VersionMeta)
-	remoteVers, err := r.RemoteVersions()
+	remoteVers, err := r.manifest(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -184,19 +296,75 @@ type VersionManifest struct {
 		Snapshot string `json:"snapshot"`
 	} `json:"latest"`
 	Versions []VersionMeta `json:"versions"`
+
+	// Source is the name of mirror manifest was downloaded from or
+	// CacheSource if it was read from cache.
+	Source string `json:"-"`
+}
+
+// CacheSource is the VersionManifest.Source value for manifest read from
+// cache.
+const CacheSource = "cache"
+
+func (r *Root) manifestCachePath() string {
+	return filepath.Join(r.LauncherDir, "version_manifest.json")
+}
+
+// manifest returns remote versions manifest, falling back to cached copy if
+// Offline is set or remote one can't be downloaded. If there is no cached
+// manifest either, empty one is returned.
+func (r *Root) manifest(ctx context.Context) (*VersionManifest, error) {
+	if !r.Offline {
+		remoteVers, err := r.RemoteVersionsContext(ctx)
+		if err == nil {
+			return remoteVers, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		log.Println("Using cached versions manifest:", err)
+	}
+
+	blob, err := ioutil.ReadFile(r.manifestCachePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &VersionManifest{Source: CacheSource}, nil
+		}
+		return nil, errors.Wrap(err, "failed to read cached versions manifest")
+	}
+	out := VersionManifest{}
+	if err := json.Unmarshal(blob, &out); err != nil {
+		return nil, errors.Wrap(err, "failed to decode cached versions manifest")
+	}
+	out.Source = CacheSource
+	return &out, nil
 }
 
 func (r *Root) RemoteVersions() (*VersionManifest, error) {
-	resp, err := http.Get(versionsUrl)
+	return r.RemoteVersionsContext(context.Background())
+}
+
+func (r *Root) RemoteVersionsContext(ctx context.Context) (*VersionManifest, error) {
+	resp, source, err := r.Net.get(ctx, r.Net.manifestURL())
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to download versions manifest")
 	}
-	if resp.StatusCode != 200 {
-		return nil, errors.New("failed to download versions manifest: HTTP " + resp.Status)
+	defer resp.Body.Close()
+	blob, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to download versions manifest")
 	}
 	var out VersionManifest
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+	if err := json.Unmarshal(blob, &out); err != nil {
 		return nil, errors.Wrap(err, "failed to decode versions manifest")
 	}
+	out.Source = source
+
+	// Keep a copy to be used when network is not available.
+	if err := os.MkdirAll(r.LauncherDir, os.ModePerm); err == nil {
+		if err := ioutil.WriteFile(r.manifestCachePath(), blob, os.ModePerm); err != nil {
+			log.Println("Failed to cache versions manifest:", err)
+		}
+	}
 	return &out, nil
 }
@@ -6,20 +6,73 @@ import (
 	"runtime"
 )
 
-func (r Rule) Applies(prof *Profile) bool {
-	if r.OS.Name != "" {
-		osName := runtime.GOOS
-		if osName == "darwin" {
-			osName = "osx"
-		}
+// Feature names used in rules.
+const (
+	FeatureDemoUser              = "is_demo_user"
+	FeatureCustomResolution      = "has_custom_resolution"
+	FeatureQuickPlaysSupport     = "has_quick_plays_support"
+	FeatureQuickPlaySingleplayer = "is_quick_play_singleplayer"
+	FeatureQuickPlayMultiplayer  = "is_quick_play_multiplayer"
+	FeatureQuickPlayRealms       = "is_quick_play_realms"
+)
+
+// Features is the set of launcher features rules are evaluated against.
+// Features that are not in the set are considered disabled.
+type Features map[string]bool
+
+// NewFeatures returns features enabled for game launched with prof and
+// authData.
+func NewFeatures(prof *Profile, authData *AuthData) Features {
+	features := make(Features)
+	if authData != nil {
+		features[FeatureDemoUser] = authData.Demo
+	}
+	if prof != nil {
+		features[FeatureCustomResolution] = prof.ResolutionWidth != 0 && prof.ResolutionHeight != 0
+		features[FeatureQuickPlaysSupport] = prof.QuickPlayPath != ""
+		features[FeatureQuickPlaySingleplayer] = prof.QuickPlaySingleplayer != ""
+		features[FeatureQuickPlayMultiplayer] = prof.QuickPlayMultiplayer != ""
+		features[FeatureQuickPlayRealms] = prof.QuickPlayRealms != ""
+	}
+	return features
+}
+
+// ruleEnv describes platform rules are evaluated for.
+type ruleEnv struct {
+	// OS is the OS name as used in rules: "linux", "osx" or "windows".
+	OS string
+	// OSVersion returns OS version, called only if rule checks it.
+	OSVersion func() (string, error)
+	// Arch is the value of Java's os.arch property.
+	Arch     string
+	Features Features
+}
+
+// currentRuleEnv returns environment of game launched on current OS with prof.
+func currentRuleEnv(prof *Profile, features Features) ruleEnv {
+	return ruleEnv{
+		OS:        ruleOSName(runtime.GOOS),
+		OSVersion: OsVersion,
+		Arch:      javaArch(prof.arch()),
+		Features:  features,
+	}
+}
 
-		osMatched, err := regexp.MatchString(r.OS.Name, osName)
+// Applies reports whether all conditions of rule match current platform,
+// prof and features.
+func (r Rule) Applies(prof *Profile, features Features) bool {
+	return r.matches(currentRuleEnv(prof, features))
+}
+
+func (r Rule) matches(env ruleEnv) bool {
+	if r.OS.Name != "" {
+		osMatched, err := regexp.MatchString(r.OS.Name, env.OS)
 		if err != nil || !osMatched {
 			return false
 		}
 	}
 	if r.OS.Version != "" {
-		ver, err := OsVersion()
+		ver, err := env.OSVersion()
 		if err != nil {
 			log.Println("Failed to get OS version:", err)
 			return false
@@ -30,37 +83,43 @@ func (r Rule) Applies(prof *Profile) bool {
 			return false
 		}
 	}
-	if r.OS.Arch != "" {
-		// Should be checked against values of Java's os.arch values.
-		// GOARCH=386   => os.arch=x86
-		// GOARCH=amd64 => os.arch=amd64
-		// TODO: other values?
-		if runtime.GOARCH == "386" {
-			return r.OS.Arch == "x86"
-		}
-		if runtime.GOARCH == "amd64" {
-			return r.OS.Arch == "amd64"
-		}
+	if r.OS.Arch != "" && r.OS.Arch != env.Arch {
 		return false
 	}
-	if r.Features.IsDemoUser != nil {
-		return !(*r.Features.IsDemoUser)
-	}
-	if r.Features.HasCustomResolution != nil {
-		return prof.ResolutionHeight != 0 && prof.ResolutionWidth != 0
+	for name, enabled := range r.Features {
+		if env.Features[name] != enabled {
+			return false
+		}
 	}
 	return true
 }
 
-func EvaluateRules(rules []Rule, profile *Profile) bool {
+// ruleOSName converts GOOS value to OS name used in rules and natives
+// classifiers.
+func ruleOSName(goos string) string {
+	if goos == "darwin" {
+		return "osx"
+	}
+	return goos
+}
+
+// EvaluateRules reports whether thing rules are attached to should be used.
+//
+// Rules are evaluated the same way as official launcher does: action of the
+// last rule that matches is taken, nothing is allowed if no rule matches. nil
+// rules list allows everything.
+func EvaluateRules(rules []Rule, profile *Profile, features Features) bool {
+	return evaluateRules(rules, currentRuleEnv(profile, features))
+}
+
+func evaluateRules(rules []Rule, env ruleEnv) bool {
 	if rules == nil {
 		return true
 	}
 
-	// TODO: Does it matches how official launcher evaluates them?
 	last := ActDisallow
 	for _, rule := range rules {
-		if rule.Applies(profile) {
+		if rule.matches(env) {
 			last = rule.Action
 		}
 	}
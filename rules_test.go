@@ -0,0 +1,257 @@
+package gomine
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// Rule snippets below are taken from version info files published by Mojang
+// unless noted otherwise.
+var rulesTests = []struct {
+	name  string
+	rules string
+	env   ruleEnv
+	allow bool
+}{
+	{
+		name:  "no rules",
+		rules: `null`,
+		env:   ruleEnv{OS: "linux"},
+		allow: true,
+	},
+	{
+		name:  "empty rules",
+		rules: `[]`,
+		env:   ruleEnv{OS: "linux"},
+		allow: false,
+	},
+	{
+		name:  "allow only",
+		rules: `[{"action": "allow"}]`,
+		env:   ruleEnv{OS: "linux"},
+		allow: true,
+	},
+
+	// lwjgl 2.9.x (1.12.2 and older)
+	{
+		name:  "allow all but osx on linux",
+		rules: `[{"action": "allow"}, {"action": "disallow", "os": {"name": "osx"}}]`,
+		env:   ruleEnv{OS: "linux"},
+		allow: true,
+	},
+	{
+		name:  "allow all but osx on osx",
+		rules: `[{"action": "allow"}, {"action": "disallow", "os": {"name": "osx"}}]`,
+		env:   ruleEnv{OS: "osx"},
+		allow: false,
+	},
+	{
+		name:  "allow osx only on osx",
+		rules: `[{"action": "allow", "os": {"name": "osx"}}]`,
+		env:   ruleEnv{OS: "osx"},
+		allow: true,
+	},
+	{
+		name:  "allow osx only on windows",
+		rules: `[{"action": "allow", "os": {"name": "osx"}}]`,
+		env:   ruleEnv{OS: "windows"},
+		allow: false,
+	},
+
+	// OS version (1.7.10 and older).
+	{
+		name:  "disallow old osx on 10.5",
+		rules: `[{"action": "allow"}, {"action": "disallow", "os": {"name": "osx", "version": "^10\\.5\\.\\d$"}}]`,
+		env:   ruleEnv{OS: "osx", OSVersion: osVersion("10.5.8")},
+		allow: false,
+	},
+	{
+		name:  "disallow old osx on 10.9",
+		rules: `[{"action": "allow"}, {"action": "disallow", "os": {"name": "osx", "version": "^10\\.5\\.\\d$"}}]`,
+		env:   ruleEnv{OS: "osx", OSVersion: osVersion("10.9.5")},
+		allow: true,
+	},
+	{
+		name:  "disallow old osx on linux",
+		rules: `[{"action": "allow"}, {"action": "disallow", "os": {"name": "osx", "version": "^10\\.5\\.\\d$"}}]`,
+		env:   ruleEnv{OS: "linux", OSVersion: osVersion("10.5.8")},
+		allow: true,
+	},
+
+	// -Dos.name=Windows 10 and -Dos.version=10.0 JVM arguments (1.13+).
+	{
+		name:  "windows 10 on windows 10",
+		rules: `[{"action": "allow", "os": {"name": "windows", "version": "^10\\."}}]`,
+		env:   ruleEnv{OS: "windows", OSVersion: osVersion("10.0")},
+		allow: true,
+	},
+	{
+		name:  "windows 10 on windows 7",
+		rules: `[{"action": "allow", "os": {"name": "windows", "version": "^10\\."}}]`,
+		env:   ruleEnv{OS: "windows", OSVersion: osVersion("6.1")},
+		allow: false,
+	},
+	{
+		name:  "windows 10 on linux 10.x",
+		rules: `[{"action": "allow", "os": {"name": "windows", "version": "^10\\."}}]`,
+		env:   ruleEnv{OS: "linux", OSVersion: osVersion("10.1.0")},
+		allow: false,
+	},
+	{
+		name:  "OS version unknown",
+		rules: `[{"action": "allow", "os": {"name": "windows", "version": "^10\\."}}]`,
+		env:   ruleEnv{OS: "windows", OSVersion: osVersionErr},
+		allow: false,
+	},
+
+	// -Xss1M JVM argument (1.13+).
+	{
+		name:  "x86 on x86",
+		rules: `[{"action": "allow", "os": {"arch": "x86"}}]`,
+		env:   ruleEnv{OS: "windows", Arch: "x86"},
+		allow: true,
+	},
+	{
+		name:  "x86 on amd64",
+		rules: `[{"action": "allow", "os": {"arch": "x86"}}]`,
+		env:   ruleEnv{OS: "windows", Arch: "amd64"},
+		allow: false,
+	},
+	{
+		name:  "x86 on aarch64",
+		rules: `[{"action": "allow", "os": {"arch": "x86"}}]`,
+		env:   ruleEnv{OS: "osx", Arch: "aarch64"},
+		allow: false,
+	},
+
+	// -XstartOnFirstThread JVM argument.
+	{
+		name:  "osx only argument on osx",
+		rules: `[{"action": "allow", "os": {"name": "osx"}}]`,
+		env:   ruleEnv{OS: "osx", Arch: "aarch64"},
+		allow: true,
+	},
+
+	// Conditions following arch check (not used by Mojang manifests yet).
+	{
+		name:  "arch and feature both match",
+		rules: `[{"action": "allow", "os": {"arch": "x86"}, "features": {"has_custom_resolution": true}}]`,
+		env:   ruleEnv{OS: "windows", Arch: "x86", Features: Features{FeatureCustomResolution: true}},
+		allow: true,
+	},
+	{
+		name:  "arch matches but feature doesn't",
+		rules: `[{"action": "allow", "os": {"arch": "x86"}, "features": {"has_custom_resolution": true}}]`,
+		env:   ruleEnv{OS: "windows", Arch: "x86"},
+		allow: false,
+	},
+	{
+		name:  "name matches but arch doesn't",
+		rules: `[{"action": "allow", "os": {"name": "windows", "arch": "x86"}}]`,
+		env:   ruleEnv{OS: "windows", Arch: "amd64"},
+		allow: false,
+	},
+
+	// --demo game argument.
+	{
+		name:  "demo user",
+		rules: `[{"action": "allow", "features": {"is_demo_user": true}}]`,
+		env:   ruleEnv{OS: "linux", Features: Features{FeatureDemoUser: true}},
+		allow: true,
+	},
+	{
+		name:  "not demo user",
+		rules: `[{"action": "allow", "features": {"is_demo_user": true}}]`,
+		env:   ruleEnv{OS: "linux", Features: Features{FeatureDemoUser: false}},
+		allow: false,
+	},
+	{
+		name:  "no features",
+		rules: `[{"action": "allow", "features": {"is_demo_user": true}}]`,
+		env:   ruleEnv{OS: "linux"},
+		allow: false,
+	},
+
+	// --width and --height game arguments.
+	{
+		name:  "custom resolution",
+		rules: `[{"action": "allow", "features": {"has_custom_resolution": true}}]`,
+		env:   ruleEnv{OS: "linux", Features: Features{FeatureCustomResolution: true}},
+		allow: true,
+	},
+	{
+		name:  "no custom resolution",
+		rules: `[{"action": "allow", "features": {"has_custom_resolution": true}}]`,
+		env:   ruleEnv{OS: "linux", Features: Features{FeatureCustomResolution: false}},
+		allow: false,
+	},
+
+	// Quick play game arguments (1.20+).
+	{
+		name:  "quick play path",
+		rules: `[{"action": "allow", "features": {"has_quick_plays_support": true}}]`,
+		env:   ruleEnv{OS: "linux", Features: Features{FeatureQuickPlaysSupport: true}},
+		allow: true,
+	},
+	{
+		name:  "quick play singleplayer",
+		rules: `[{"action": "allow", "features": {"is_quick_play_singleplayer": true}}]`,
+		env:   ruleEnv{OS: "linux", Features: Features{FeatureQuickPlaySingleplayer: true}},
+		allow: true,
+	},
+	{
+		name:  "quick play multiplayer without it",
+		rules: `[{"action": "allow", "features": {"is_quick_play_multiplayer": true}}]`,
+		env:   ruleEnv{OS: "linux", Features: Features{FeatureQuickPlaySingleplayer: true}},
+		allow: false,
+	},
+	{
+		name:  "quick play realms",
+		rules: `[{"action": "allow", "features": {"is_quick_play_realms": true}}]`,
+		env:   ruleEnv{OS: "linux", Features: Features{FeatureQuickPlayRealms: true}},
+		allow: true,
+	},
+
+	// Not used by Mojang manifests.
+	{
+		name:  "feature required to be disabled",
+		rules: `[{"action": "allow", "features": {"is_demo_user": false}}]`,
+		env:   ruleEnv{OS: "linux"},
+		allow: true,
+	},
+	{
+		name:  "multiple features",
+		rules: `[{"action": "allow", "features": {"is_demo_user": true, "has_custom_resolution": true}}]`,
+		env:   ruleEnv{OS: "linux", Features: Features{FeatureDemoUser: true}},
+		allow: false,
+	},
+	{
+		name:  "last matching rule wins",
+		rules: `[{"action": "disallow", "os": {"name": "linux"}}, {"action": "allow", "os": {"name": "linux"}}, {"action": "disallow", "os": {"name": "osx"}}]`,
+		env:   ruleEnv{OS: "linux"},
+		allow: true,
+	},
+}
+
+func osVersion(ver string) func() (string, error) {
+	return func() (string, error) {
+		return ver, nil
+	}
+}
+
+func osVersionErr() (string, error) {
+	return "", errors.New("unknown")
+}
+
+func TestEvaluateRules(t *testing.T) {
+	for _, test := range rulesTests {
+		var rules []Rule
+		if err := json.Unmarshal([]byte(test.rules), &rules); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if allow := evaluateRules(rules, test.env); allow != test.allow {
+			t.Errorf("%s: got %v, want %v", test.name, allow, test.allow)
+		}
+	}
+}
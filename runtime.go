@@ -0,0 +1,250 @@
+package gomine
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoJavaRuntime is returned by InstallJavaRuntime if requested runtime is
+// not available for current platform.
+var ErrNoJavaRuntime = errors.New("Java runtime is not available for this platform")
+
+type javaRuntimeEntry struct {
+	Manifest Artifact `json:"manifest"`
+	Version  struct {
+		Name string `json:"name"`
+	} `json:"version"`
+}
+
+type javaRuntimeFile struct {
+	// Type is one of "file", "directory" or "link".
+	Type       string `json:"type"`
+	Executable bool   `json:"executable"`
+	Target     string `json:"target"`
+	Downloads  struct {
+		Raw *Artifact `json:"raw"`
+	} `json:"downloads"`
+}
+
+// javaRuntimeInstall is the list of actions needed to install Java runtime.
+type javaRuntimeInstall struct {
+	jobs        []downloadJob
+	dirs        []string
+	executables []string
+	// link path => target
+	links map[string]string
+}
+
+// javaRuntimePlatform returns platform name used in Java runtimes manifest.
+func javaRuntimePlatform(goos, goarch string) string {
+	switch goos {
+	case "linux":
+		switch goarch {
+		case "amd64":
+			return "linux"
+		case "386":
+			return "linux-i386"
+		}
+	case "darwin":
+		switch goarch {
+		case "amd64":
+			return "mac-os"
+		case "arm64":
+			return "mac-os-arm64"
+		}
+	case "windows":
+		switch goarch {
+		case "amd64":
+			return "windows-x64"
+		case "386":
+			return "windows-x86"
+		case "arm64":
+			return "windows-arm64"
+		}
+	}
+	return goos + "-" + goarch
+}
+
+// JavaRuntimeDir returns directory Java runtime component is installed to.
+func (r *Root) JavaRuntimeDir(component string) string {
+	return filepath.Join(r.LauncherDir, "runtime", component)
+}
+
+// javaRuntimeBinary returns path to java executable of runtime installed to
+// dir.
+func javaRuntimeBinary(dir string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(dir, "bin", "java.exe")
+	case "darwin":
+		return filepath.Join(dir, "jre.bundle", "Contents", "Home", "bin", "java")
+	}
+	return filepath.Join(dir, "bin", "java")
+}
+
+// installedJavaRuntime returns path to java executable of runtime required by
+// version or empty string if it is not installed.
+func (r *Root) installedJavaRuntime(ver *Version) string {
+	if ver.JavaVersion.Component == "" {
+		return ""
+	}
+	bin := javaRuntimeBinary(r.JavaRuntimeDir(ver.JavaVersion.Component))
+	if _, err := os.Stat(bin); err != nil {
+		return ""
+	}
+	return bin
+}
+
+// javaForVersion returns path to java executable to run ver with: Java
+// runtime installed for it or, if there is none, best matching Java
+// installation found on the machine.
+func (r *Root) javaForVersion(ver *Version) (string, error) {
+	if bin := r.installedJavaRuntime(ver); bin != "" {
+		return bin, nil
+	}
+	return findJava(ver.JavaVersion.MajorVersion)
+}
+
+func (r *Root) InstallJavaRuntime(component string) error {
+	return r.InstallJavaRuntimeContext(context.Background(), component)
+}
+
+// InstallJavaRuntimeContext downloads Java runtime component (see
+// JavaVersion.Component) from Mojang servers to JavaRuntimeDir. Files that
+// are already installed and have matching hash are not downloaded again.
+func (r *Root) InstallJavaRuntimeContext(ctx context.Context, component string) error {
+	install, err := r.planJavaRuntime(ctx, component, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+
+	d := r.downloader()
+	if d.Progress != nil {
+		d.Progress.Planned(plannedSize(install.jobs))
+	}
+	if err := d.run(ctx, install.jobs); err != nil {
+		return err
+	}
+	return install.finish()
+}
+
+// planJavaRuntime downloads runtime manifest for component and returns list
+// of files to install.
+func (r *Root) planJavaRuntime(ctx context.Context, component, goarch string) (*javaRuntimeInstall, error) {
+	var runtimes map[string]map[string][]javaRuntimeEntry
+	if err := r.getJSON(ctx, r.Net.javaRuntimesURL(), "", &runtimes); err != nil {
+		return nil, errors.Wrap(err, "failed to get Java runtimes manifest")
+	}
+	entries := runtimes[javaRuntimePlatform(runtime.GOOS, goarch)][component]
+	if len(entries) == 0 {
+		return nil, ErrNoJavaRuntime
+	}
+
+	var manifest struct {
+		Files map[string]javaRuntimeFile `json:"files"`
+	}
+	if err := r.getJSON(ctx, entries[0].Manifest.URL, entries[0].Manifest.SHA1, &manifest); err != nil {
+		return nil, errors.Wrapf(err, "failed to get manifest for Java runtime %s", component)
+	}
+
+	dir := r.JavaRuntimeDir(component)
+	install := &javaRuntimeInstall{links: make(map[string]string)}
+
+	// Sort so output doesn't depend on map order.
+	names := make([]string, 0, len(manifest.Files))
+	for name := range manifest.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		file := manifest.Files[name]
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return nil, errors.New("malformed Java runtime manifest: file outside of runtime directory: " + name)
+		}
+
+		switch file.Type {
+		case "directory":
+			install.dirs = append(install.dirs, path)
+		case "file":
+			if file.Downloads.Raw == nil {
+				return nil, errors.New("malformed Java runtime manifest: no download for " + name)
+			}
+			install.jobs = append(install.jobs, file.Downloads.Raw.job(component+": "+name, path))
+			if file.Executable {
+				install.executables = append(install.executables, path)
+			}
+		case "link":
+			install.links[path] = file.Target
+		}
+	}
+	return install, nil
+}
+
+// finish creates empty directories, sets executable bits and creates
+// symlinks after files are downloaded.
+func (ri *javaRuntimeInstall) finish() error {
+	for _, dir := range ri.dirs {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return errors.Wrap(err, "failed to create directory")
+		}
+	}
+	if runtime.GOOS != "windows" {
+		for _, path := range ri.executables {
+			if err := os.Chmod(path, 0755); err != nil {
+				return errors.Wrap(err, "failed to make file executable")
+			}
+		}
+	}
+
+	for path, target := range ri.links {
+		if current, err := os.Readlink(path); err == nil {
+			if current == target {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return errors.Wrap(err, "failed to replace symlink")
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			return errors.Wrap(err, "failed to create directory")
+		}
+		if err := os.Symlink(target, path); err != nil {
+			return errors.Wrap(err, "failed to create symlink")
+		}
+	}
+	return nil
+}
+
+// getJSON downloads JSON document from url and decodes it into out. If hash
+// is not empty, document is checked against it.
+func (r *Root) getJSON(ctx context.Context, url, hash string, out interface{}) error {
+	resp, _, err := r.Net.get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	blob, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if hash != "" {
+		sum := sha1.Sum(blob)
+		if hex.EncodeToString(sum[:]) != hash {
+			return errors.New("hash mismatch")
+		}
+	}
+	return json.Unmarshal(blob, out)
+}
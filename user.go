@@ -9,6 +9,22 @@ type Profile struct {
 	CustomGameArgs 					  string
 
 	ResolutionWidth, ResolutionHeight int
+
+	// QuickPlayPath is the file quick play results are logged to
+	// (relative to GameDir). Setting it enables quick play support.
+	QuickPlayPath string
+
+	// QuickPlaySingleplayer, QuickPlayMultiplayer and QuickPlayRealms
+	// specify world name, server address or realm ID to join right after
+	// game start. At most one should be set.
+	QuickPlaySingleplayer string
+	QuickPlayMultiplayer  string
+	QuickPlayRealms       string
+
+	// Arch is the architecture (as GOARCH value) game is installed and
+	// launched for, used to evaluate rules and select natives. Defaults
+	// to architecture launcher runs on.
+	Arch string
 }
 
 type AuthData struct {
@@ -16,4 +32,7 @@ type AuthData struct {
 	PlayerName string
 	UUID string
 	Token string
+
+	// Demo is set for accounts that don't own the game.
+	Demo bool
 }
\ No newline at end of file
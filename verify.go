@@ -0,0 +1,224 @@
+package gomine
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// FileProblem describes missing or corrupted file found by VerifyVersion.
+type FileProblem struct {
+	// Name is the human-readable description of the file, such as
+	// library name.
+	Name string
+	Path string
+
+	// Reason is the short description of the problem, e.g. "hash mismatch".
+	Reason string
+
+	job downloadJob
+}
+
+// VerifyReport is the result of VerifyVersion.
+type VerifyReport struct {
+	Missing []FileProblem
+	Corrupt []FileProblem
+
+	// Extra lists files in version directory (including extracted natives)
+	// that don't belong to the version.
+	Extra []string
+
+	// AssetsUnchecked is set if asset objects were not verified because
+	// assets index is missing or corrupted.
+	AssetsUnchecked bool
+}
+
+// OK reports whether no missing or corrupted files were found.
+func (vr *VerifyReport) OK() bool {
+	return len(vr.Missing) == 0 && len(vr.Corrupt) == 0 && !vr.AssetsUnchecked
+}
+
+func (vr *VerifyReport) check(job downloadJob) error {
+	problem := FileProblem{Name: job.Name, Path: job.TargetPath, job: job}
+
+	info, err := os.Stat(job.TargetPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			problem.Reason = "missing"
+			vr.Missing = append(vr.Missing, problem)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to check %s", job.Name)
+	}
+
+	if job.Size != 0 && uint64(info.Size()) != job.Size {
+		problem.Reason = "size mismatch"
+		vr.Corrupt = append(vr.Corrupt, problem)
+		return nil
+	}
+
+	if job.SHA1 == "" {
+		return nil
+	}
+	hash, err := fileSHA1(job.TargetPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check %s", job.Name)
+	}
+	if hash != job.SHA1 {
+		problem.Reason = "hash mismatch"
+		vr.Corrupt = append(vr.Corrupt, problem)
+	}
+	return nil
+}
+
+// VerifyVersion checks libraries, natives, assets index, asset objects,
+// logging config and client jar of installed version against their sizes and
+// hashes. Nothing is downloaded.
+func (r *Root) VerifyVersion(ver *Version) (*VerifyReport, error) {
+	report := &VerifyReport{}
+
+	jobs, err := ver.libraryJobs(r.LibrariesDir(), nil)
+	if err != nil {
+		return nil, err
+	}
+	jobs = append(jobs, ver.clientJob(r.VersionsDir()))
+	jobs = append(jobs, ver.logConfigJobs(r.AssetsDir())...)
+	for _, job := range jobs {
+		if err := report.check(job); err != nil {
+			return nil, err
+		}
+	}
+
+	brokenBefore := len(report.Missing) + len(report.Corrupt)
+	if err := report.check(ver.assetIndexJob(r.AssetsDir())); err != nil {
+		return nil, err
+	}
+	if len(report.Missing)+len(report.Corrupt) != brokenBefore {
+		report.AssetsUnchecked = true
+	} else {
+		assetJobs, err := ver.assetJobs(r.AssetsDir())
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range assetJobs {
+			if err := report.check(job); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	report.Extra, err = r.extraVersionFiles(ver)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// extraVersionFiles lists files in version directory that are not version
+// JSON, client jar, list of Forge processor outputs or natives extracted
+// from version libraries.
+func (r *Root) extraVersionFiles(ver *Version) ([]string, error) {
+	versionDir := filepath.Join(r.VersionsDir(), ver.ID)
+	nativesDir := filepath.Join(versionDir, "natives")
+
+	expected := map[string]bool{
+		filepath.Join(versionDir, ver.ID+".json"):     true,
+		filepath.Join(versionDir, ver.jarID()+".jar"): true,
+		r.processorOutputsPath(ver.ID):                true,
+	}
+	for _, lib := range ver.activeLibraries(nil) {
+		path, err := lib.nativesJarPath(nil)
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			continue
+		}
+
+		zipReader, err := zip.OpenReader(filepath.Join(r.LibrariesDir(), path))
+		if err != nil {
+			// Missing or broken jar is reported separately.
+			continue
+		}
+		for _, file := range zipReader.File {
+			if target := lib.nativeTarget(file); target != "" {
+				expected[filepath.Join(nativesDir, target)] = true
+			}
+		}
+		zipReader.Close()
+	}
+
+	var extra []string
+	err := filepath.Walk(versionDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || expected[path] {
+			return nil
+		}
+		extra = append(extra, path)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list version files")
+	}
+	return extra, nil
+}
+
+// RepairVersion verifies version files and downloads again only missing and
+// corrupted ones. Returned report describes state before repair.
+func (r *Root) RepairVersion(ver *Version) (*VerifyReport, error) {
+	return r.RepairVersionContext(context.Background(), ver)
+}
+
+func (r *Root) RepairVersionContext(ctx context.Context, ver *Version) (*VerifyReport, error) {
+	report, err := r.VerifyVersion(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	d := r.downloader()
+	jobs := make([]downloadJob, 0, len(report.Missing)+len(report.Corrupt))
+	for _, problem := range append(report.Missing, report.Corrupt...) {
+		jobs = append(jobs, problem.job)
+	}
+	if d.Progress != nil {
+		d.Progress.Planned(plannedSize(jobs))
+	}
+	if err := d.run(ctx, jobs); err != nil {
+		return report, err
+	}
+
+	if report.AssetsUnchecked {
+		// Index is fixed now, check objects it references.
+		assetJobs, err := ver.assetJobs(r.AssetsDir())
+		if err != nil {
+			return report, err
+		}
+		assetsReport := VerifyReport{}
+		for _, job := range assetJobs {
+			if err := assetsReport.check(job); err != nil {
+				return report, err
+			}
+		}
+		jobs = jobs[:0]
+		for _, problem := range append(assetsReport.Missing, assetsReport.Corrupt...) {
+			jobs = append(jobs, problem.job)
+		}
+		if err := d.run(ctx, jobs); err != nil {
+			return report, err
+		}
+	}
+
+	nativesDir := filepath.Join(r.VersionsDir(), ver.ID, "natives")
+	if err := ver.ExtractNatives(r.LibrariesDir(), nativesDir); err != nil {
+		return report, err
+	}
+	return report, nil
+}
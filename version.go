@@ -1,5 +1,7 @@
 package gomine
 
+import "encoding/json"
+
 type Asset struct {
 	Hash string `json:"hash"`
 	Size uint64 `json:"size"`
@@ -7,12 +9,24 @@ type Asset struct {
 
 type AssetIndexContents struct {
 	Objects map[string]Asset `json:"objects"`
+
+	// Virtual is set for pre-1.7.2 indexes, objects should be copied to
+	// assets/virtual/<index id>/ under their names.
+	Virtual bool `json:"virtual"`
+
+	// MapToResources is set for pre-1.6 indexes, objects should be copied
+	// to <game dir>/resources/ under their names.
+	MapToResources bool `json:"map_to_resources"`
 }
 
 type Artifact struct {
 	SHA1 string `json:"sha1"`
 	Size uint64 `json:"size"`
 	URL  string `json:"url"`
+
+	// Path is the location of library artifact relative to libraries
+	// directory, always uses forward slashes.
+	Path string `json:"path"`
 }
 
 type RuleAct string
@@ -30,10 +44,9 @@ type Rule struct {
 		Version string `json:"version" mapstructure:"version"`
 		Arch    string `json:"arch" mapstructure:"arch"`
 	} `json:"os" mapstructure:"os"`
-	Features struct {
-		IsDemoUser *bool `json:"is_demo_user" mapstructure:"is_demo_user"`
-		HasCustomResolution *bool `json:"has_custom_resolution" mapstructure:"has_custom_resolution"`
-	} `json:"features" mapstructure:"features"`
+	// Features required to be enabled (or disabled) for rule to match,
+	// see Features.
+	Features map[string]bool `json:"features" mapstructure:"features"`
 }
 
 type AssetIndex struct {
@@ -42,54 +55,110 @@ type AssetIndex struct {
 	Artifact
 }
 
-type LibClassifiers struct {
-	JavaDoc      *Artifact `json:"javadoc"`
-	NativesLinux *Artifact `json:"natives-linux"`
-	NativesMacOS *Artifact `json:"natives-osx"`
-	NativesWin   *Artifact `json:"natives-windows"`
-	Sources      *Artifact `json:"sources"`
-}
+// LibClassifiers maps classifier (like "natives-linux" or "sources") to
+// artifact.
+type LibClassifiers map[string]*Artifact
 
 type Lib struct {
 	Downloads struct {
-		MainJar        *Artifact `json:"artifact"`
-		LibClassifiers `json:"classifiers"`
+		MainJar     *Artifact      `json:"artifact"`
+		Classifiers LibClassifiers `json:"classifiers"`
 	} `json:"downloads"`
-	NativeSuffixes struct {
-		Linux   string `json:"linux"`
-		MacOS   string `json:"osx"`
-		Windows string `json:"windows"`
-	} `json:"natives"`
+
+	// NativeSuffixes maps OS name as used in rules ("linux", "osx",
+	// "windows") to classifier of natives artifact. Classifier may contain
+	// ${arch} placeholder.
+	//
+	// Since 1.19 natives are listed as separate libraries instead.
+	NativeSuffixes map[string]string `json:"natives"`
+
 	Name         string `json:"name"`
 	Rules        []Rule `json:"rules"`
 	ExtractRules struct {
 		Exclude []string `json:"exclude"`
 	} `json:"extract"`
+
+	// URL is the Maven repository library should be downloaded from if
+	// there is no downloads block (used by Fabric, Quilt and Forge).
+	URL  string `json:"url"`
+	SHA1 string `json:"sha1"`
+	Size uint64 `json:"size"`
+
+	// Extra contains fields of library object unknown to gomine, they
+	// are preserved by WriteVersionJSON.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
-// TODO
+// LogCfg describes logging configuration file passed to the game.
 type LogCfg struct {
-	JVMArg string
-	Type   string
-	File   Artifact `json:"file"`
+	// Argument is the JVM argument used to pass configuration file,
+	// ${path} in it is replaced with file location.
+	Argument string `json:"argument"`
+	Type     string `json:"type"`
+	File     struct {
+		ID string `json:"id"`
+		Artifact
+	} `json:"file"`
+}
+
+// JavaVersion is the Java runtime required by version.
+type JavaVersion struct {
+	// Component is the name of runtime in Mojang's Java runtimes
+	// manifest, e.g. "java-runtime-gamma".
+	Component    string `json:"component"`
+	MajorVersion int    `json:"majorVersion"`
 }
 
 type Argument struct {
-	Value string `json:"value"`
-	Rules []Rule `json:"rules"`
+	Value []string `json:"value"`
+	Rules []Rule   `json:"rules"`
+
+	// valueList is set if value was specified as JSON list even though it
+	// has only one element.
+	valueList bool
 }
 
 type Version struct {
 	AssetIndex AssetIndex `json:"assetIndex"`
+	Assets     string     `json:"assets"`
 	Downloads  struct {
-		Client Artifact `json:"client"`
-		Server Artifact `json:"server"`
+		Client         Artifact  `json:"client"`
+		ClientMappings *Artifact `json:"client_mappings"`
+		Server         Artifact  `json:"server"`
+		ServerMappings *Artifact `json:"server_mappings"`
+		WindowsServer  *Artifact `json:"windows_server"`
 	} `json:"downloads"`
-	ID        string `json:"id"`
-	Libraries []Lib  `json:"libraries"`
-	//ClientLog LogCfg
+	ID          string      `json:"id"`
+	JavaVersion JavaVersion `json:"javaVersion"`
+	Libraries   []Lib       `json:"libraries"`
+	Logging     struct {
+		Client *LogCfg `json:"client"`
+	} `json:"logging"`
 	MainClass string `json:"mainClass"`
 	GameArgs  []Argument
 	JVMArgs   []Argument
 	Type      string `json:"type"`
+
+	// MinecraftArguments is the game arguments string used by versions
+	// before 1.13. GameArgs are built from it.
+	MinecraftArguments string `json:"minecraftArguments"`
+
+	// InheritsFrom is the ID of version this one is based on. It is empty
+	// for versions returned by Root.GetVersion since they are already
+	// merged with their parents.
+	InheritsFrom string `json:"inheritsFrom"`
+
+	// Jar is the ID of version whose client jar should be used. Defaults to
+	// ID.
+	Jar string `json:"jar"`
+
+	MinimumLauncherVersion uint `json:"minimumLauncherVersion"`
+
+	// ReleaseTime and Time are timestamps in RFC 3339 format.
+	ReleaseTime string `json:"releaseTime"`
+	Time        string `json:"time"`
+
+	// Extra contains top-level fields unknown to gomine, they are
+	// preserved by WriteVersionJSON.
+	Extra map[string]json.RawMessage `json:"-"`
 }
@@ -1,6 +1,7 @@
 package gomine
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"strings"
@@ -11,29 +12,26 @@ import (
 var ErrIncompatibleFormat = errors.New("ReadVersionJSON: JSON format version is higher than supported by library")
 var ErrInvalidFormat = errors.New("ReadVersionJSON: passed JSON object doesn't matches expected schema")
 
+// defaultJVMArgs are used for versions that don't specify JVM arguments
+// (all versions before 1.13).
 var defaultJVMArgs = []Argument{
-	{Value: "-Xss1M"},
-	{Value: "-Djava.library.path=${natives_directory}"},
-	{Value: "-Dminecraft.launcher.brand=${launcher_name}"},
-	{Value: "-Dminecraft.launcher.version=${launcher_version}"},
-	{Value: "-cp"},
-	{Value: "${classpath}"},
-	{Value: "-Xmx2G"},
-	{Value: "-XX:+UnlockExperimentalVMOptions"},
-	{Value: "-XX:+UseG1GC"},
-	{Value: "-XX:G1NewSizePercent=20"},
-	{Value: "-XX:G1ReservePercent=20"},
-	{Value: "-XX:MaxGCPauseMillis=50"},
-	{Value: "-XX:G1HeapRegionSize=32M"},
+	{Value: []string{"-Xss1M"}},
+	{Value: []string{"-Djava.library.path=${natives_directory}"}},
+	{Value: []string{"-Dminecraft.launcher.brand=${launcher_name}"}},
+	{Value: []string{"-Dminecraft.launcher.version=${launcher_version}"}},
+	{Value: []string{"-cp"}},
+	{Value: []string{"${classpath}"}},
+	{Value: []string{"-Xmx2G"}},
+	{Value: []string{"-XX:+UnlockExperimentalVMOptions"}},
+	{Value: []string{"-XX:+UseG1GC"}},
+	{Value: []string{"-XX:G1NewSizePercent=20"}},
+	{Value: []string{"-XX:G1ReservePercent=20"}},
+	{Value: []string{"-XX:MaxGCPauseMillis=50"}},
+	{Value: []string{"-XX:G1HeapRegionSize=32M"}},
 }
 
 type versionJson struct {
 	Version
-	MinimumLauncherVersion uint   `json:"minimumLauncherVersion"`
-	MinecraftArgs          string `json:"minecraftArguments"`
-	Logging                struct {
-		Client LogCfg `json:"client"`
-	}
 	Arguments struct {
 		Game []interface{} `json:"game"`
 		JVM  []interface{} `json:"jvm"`
@@ -45,14 +43,14 @@ func processRawArgs(raw []interface{}) ([]Argument, error) {
 	for _, arg := range raw {
 		switch arg.(type) {
 		case string:
-			res = append(res, Argument{Value: arg.(string)})
+			res = append(res, Argument{Value: []string{arg.(string)}})
 		case map[string]interface{}:
 			mapArg := arg.(map[string]interface{})
 			saneArg := Argument{}
 
 			switch mapArg["value"].(type) {
 			case string:
-				saneArg.Value = mapArg["value"].(string)
+				saneArg.Value = []string{mapArg["value"].(string)}
 			case []interface{}:
 				strArr := []string{}
 				for _, rawVal := range mapArg["value"].([]interface{}) {
@@ -63,7 +61,8 @@ func processRawArgs(raw []interface{}) ([]Argument, error) {
 					strArr = append(strArr, str)
 				}
 
-				saneArg.Value = strings.Join(strArr, " ")
+				saneArg.Value = strArr
+				saneArg.valueList = true
 			default:
 				return res, ErrInvalidFormat
 			}
@@ -88,6 +87,9 @@ func processRawArgs(raw []interface{}) ([]Argument, error) {
 	return res, nil
 }
 
+// ReadVersionJSON parses version info. Version info requiring newer launcher
+// (minimumLauncherVersion above 21) is parsed the same way, fields unknown to
+// gomine are kept in Extra.
 func ReadVersionJSON(in []byte) (*Version, error) {
 	raw := versionJson{}
 	var err error
@@ -95,9 +97,8 @@ func ReadVersionJSON(in []byte) (*Version, error) {
 	if err = json.Unmarshal(in, &raw); err != nil {
 		return nil, err
 	}
-
-	if raw.MinimumLauncherVersion > 21 {
-		return &raw.Version, err
+	if err = readExtraFields(in, &raw.Version); err != nil {
+		return nil, err
 	}
 
 	if raw.Arguments.Game != nil {
@@ -113,16 +114,292 @@ func ReadVersionJSON(in []byte) (*Version, error) {
 		}
 	}
 
-	if raw.MinecraftArgs != "" {
+	if raw.MinecraftArguments != "" {
 		raw.Version.GameArgs = []Argument{}
-		for _, arg := range strings.Split(raw.MinecraftArgs, " ") {
-			raw.Version.GameArgs = append(raw.Version.GameArgs, Argument{Value: arg})
+		for _, arg := range strings.Split(raw.MinecraftArguments, " ") {
+			raw.Version.GameArgs = append(raw.Version.GameArgs, Argument{Value: []string{arg}})
 		}
 	}
 
-	if raw.Version.JVMArgs == nil || len(raw.Version.JVMArgs) == 0 {
-		raw.Version.JVMArgs = defaultJVMArgs
+	return &raw.Version, nil
+}
+
+// versionFields and libFields list keys of version and library objects
+// known to gomine, other ones are kept in Extra.
+var (
+	versionFields = []string{
+		"arguments", "assetIndex", "assets", "downloads", "id", "inheritsFrom",
+		"jar", "javaVersion", "libraries", "logging", "mainClass", "minecraftArguments",
+		"minimumLauncherVersion", "releaseTime", "time", "type",
+	}
+	libFields = []string{
+		"downloads", "extract", "name", "natives", "rules", "sha1", "size", "url",
 	}
+)
 
-	return &raw.Version, nil
+// readExtraFields fills Extra of version and its libraries.
+func readExtraFields(in []byte, v *Version) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(in, &fields); err != nil {
+		return err
+	}
+
+	if libsBlob, ok := fields["libraries"]; ok {
+		var libsFields []map[string]json.RawMessage
+		if err := json.Unmarshal(libsBlob, &libsFields); err != nil {
+			return err
+		}
+		for i := range v.Libraries {
+			if i < len(libsFields) {
+				v.Libraries[i].Extra = unknownFields(libsFields[i], libFields)
+			}
+		}
+	}
+
+	v.Extra = unknownFields(fields, versionFields)
+	return nil
+}
+
+func unknownFields(fields map[string]json.RawMessage, known []string) map[string]json.RawMessage {
+	for _, key := range known {
+		delete(fields, key)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// WriteVersionJSON serializes version info in the format used by Mojang, so
+// unmodified version info published by Mojang and read using ReadVersionJSON
+// is written back byte-for-byte. Version info from other sources (e.g. Fabric
+// meta) keeps its contents but is reformatted.
+//
+// minecraftArguments string of versions before 1.13 is rebuilt from GameArgs.
+func WriteVersionJSON(v *Version) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := writeMojangJSON(&buf, v.jsonValue()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (v *Version) jsonValue() map[string]interface{} {
+	obj := make(map[string]interface{}, len(versionFields)+len(v.Extra))
+	for key, val := range v.Extra {
+		obj[key] = val
+	}
+
+	if v.MinecraftArguments != "" {
+		values := make([]string, 0, len(v.GameArgs))
+		for _, arg := range v.GameArgs {
+			values = append(values, arg.Value...)
+		}
+		obj["minecraftArguments"] = strings.Join(values, " ")
+		if v.JVMArgs != nil {
+			obj["arguments"] = map[string]interface{}{"jvm": argsJSONValue(v.JVMArgs)}
+		}
+	} else if v.GameArgs != nil || v.JVMArgs != nil {
+		args := make(map[string]interface{}, 2)
+		if v.GameArgs != nil {
+			args["game"] = argsJSONValue(v.GameArgs)
+		}
+		if v.JVMArgs != nil {
+			args["jvm"] = argsJSONValue(v.JVMArgs)
+		}
+		obj["arguments"] = args
+	}
+
+	if v.AssetIndex.ID != "" {
+		index := v.AssetIndex.Artifact.jsonValue()
+		index["id"] = v.AssetIndex.ID
+		index["totalSize"] = v.AssetIndex.TotalSize
+		obj["assetIndex"] = index
+	}
+
+	downloads := make(map[string]interface{})
+	if v.Downloads.Client.URL != "" {
+		downloads["client"] = v.Downloads.Client.jsonValue()
+	}
+	if v.Downloads.ClientMappings != nil {
+		downloads["client_mappings"] = v.Downloads.ClientMappings.jsonValue()
+	}
+	if v.Downloads.Server.URL != "" {
+		downloads["server"] = v.Downloads.Server.jsonValue()
+	}
+	if v.Downloads.ServerMappings != nil {
+		downloads["server_mappings"] = v.Downloads.ServerMappings.jsonValue()
+	}
+	if v.Downloads.WindowsServer != nil {
+		downloads["windows_server"] = v.Downloads.WindowsServer.jsonValue()
+	}
+	if len(downloads) != 0 {
+		obj["downloads"] = downloads
+	}
+
+	if v.Libraries != nil {
+		libs := make([]interface{}, 0, len(v.Libraries))
+		for _, lib := range v.Libraries {
+			libs = append(libs, lib.jsonValue())
+		}
+		obj["libraries"] = libs
+	}
+
+	if v.Logging.Client != nil {
+		file := v.Logging.Client.File.Artifact.jsonValue()
+		file["id"] = v.Logging.Client.File.ID
+		obj["logging"] = map[string]interface{}{
+			"client": map[string]interface{}{
+				"argument": v.Logging.Client.Argument,
+				"file":     file,
+				"type":     v.Logging.Client.Type,
+			},
+		}
+	}
+
+	if v.JavaVersion.Component != "" || v.JavaVersion.MajorVersion != 0 {
+		obj["javaVersion"] = map[string]interface{}{
+			"component":    v.JavaVersion.Component,
+			"majorVersion": v.JavaVersion.MajorVersion,
+		}
+	}
+
+	if v.MinimumLauncherVersion != 0 {
+		obj["minimumLauncherVersion"] = v.MinimumLauncherVersion
+	}
+
+	obj["id"] = v.ID
+	setJSONString(obj, "assets", v.Assets)
+	setJSONString(obj, "inheritsFrom", v.InheritsFrom)
+	setJSONString(obj, "jar", v.Jar)
+	setJSONString(obj, "mainClass", v.MainClass)
+	setJSONString(obj, "releaseTime", v.ReleaseTime)
+	setJSONString(obj, "time", v.Time)
+	setJSONString(obj, "type", v.Type)
+	return obj
+}
+
+func (l *Lib) jsonValue() map[string]interface{} {
+	obj := make(map[string]interface{}, len(libFields)+len(l.Extra))
+	for key, val := range l.Extra {
+		obj[key] = val
+	}
+
+	downloads := make(map[string]interface{})
+	if l.Downloads.MainJar != nil {
+		downloads["artifact"] = l.Downloads.MainJar.jsonValue()
+	}
+	if l.Downloads.Classifiers != nil {
+		classifiers := make(map[string]interface{}, len(l.Downloads.Classifiers))
+		for classifier, artifact := range l.Downloads.Classifiers {
+			if artifact != nil {
+				classifiers[classifier] = artifact.jsonValue()
+			}
+		}
+		downloads["classifiers"] = classifiers
+	}
+	if len(downloads) != 0 {
+		obj["downloads"] = downloads
+	}
+
+	if l.ExtractRules.Exclude != nil {
+		obj["extract"] = map[string]interface{}{"exclude": stringsJSONValue(l.ExtractRules.Exclude)}
+	}
+	if l.NativeSuffixes != nil {
+		natives := make(map[string]interface{}, len(l.NativeSuffixes))
+		for osName, classifier := range l.NativeSuffixes {
+			natives[osName] = classifier
+		}
+		obj["natives"] = natives
+	}
+	if l.Rules != nil {
+		obj["rules"] = rulesJSONValue(l.Rules)
+	}
+	if l.Size != 0 {
+		obj["size"] = l.Size
+	}
+
+	obj["name"] = l.Name
+	setJSONString(obj, "sha1", l.SHA1)
+	setJSONString(obj, "url", l.URL)
+	return obj
+}
+
+func (a *Artifact) jsonValue() map[string]interface{} {
+	obj := map[string]interface{}{
+		"sha1": a.SHA1,
+		"size": a.Size,
+		"url":  a.URL,
+	}
+	setJSONString(obj, "path", a.Path)
+	return obj
+}
+
+func (r *Rule) jsonValue() map[string]interface{} {
+	obj := map[string]interface{}{
+		"action": string(r.Action),
+	}
+
+	osObj := make(map[string]interface{})
+	setJSONString(osObj, "name", r.OS.Name)
+	setJSONString(osObj, "version", r.OS.Version)
+	setJSONString(osObj, "arch", r.OS.Arch)
+	if len(osObj) != 0 {
+		obj["os"] = osObj
+	}
+
+	if r.Features != nil {
+		features := make(map[string]interface{}, len(r.Features))
+		for name, enabled := range r.Features {
+			features[name] = enabled
+		}
+		obj["features"] = features
+	}
+	return obj
+}
+
+func rulesJSONValue(rules []Rule) []interface{} {
+	res := make([]interface{}, 0, len(rules))
+	for _, rule := range rules {
+		res = append(res, rule.jsonValue())
+	}
+	return res
+}
+
+// argsJSONValue converts arguments back to the form used in version info:
+// plain strings for arguments without rules, objects for other ones.
+func argsJSONValue(args []Argument) []interface{} {
+	res := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		var value interface{} = stringsJSONValue(arg.Value)
+		if len(arg.Value) == 1 && !arg.valueList {
+			if arg.Rules == nil {
+				res = append(res, arg.Value[0])
+				continue
+			}
+			value = arg.Value[0]
+		}
+
+		obj := map[string]interface{}{"value": value}
+		if arg.Rules != nil {
+			obj["rules"] = rulesJSONValue(arg.Rules)
+		}
+		res = append(res, obj)
+	}
+	return res
+}
+
+func stringsJSONValue(strs []string) []interface{} {
+	res := make([]interface{}, 0, len(strs))
+	for _, str := range strs {
+		res = append(res, str)
+	}
+	return res
+}
+
+// setJSONString sets obj[key] to value unless it is empty.
+func setJSONString(obj map[string]interface{}, key, value string) {
+	if value != "" {
+		obj[key] = value
+	}
 }
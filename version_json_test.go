@@ -0,0 +1,86 @@
+package gomine
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Files in testdata are reduced copies of real version info files: library
+// lists are shortened and hashes and URLs are not real, formatting and
+// structure are kept as published.
+var roundTripTests = []struct {
+	file string
+
+	// Version info files published by Mojang are written back
+	// byte-for-byte, others only need to keep the same contents.
+	exact bool
+}{
+	{file: "1.20.1.json", exact: true},
+	{file: "1.12.2.json", exact: true},
+	// Fabric meta, uses compact formatting and its own key order.
+	{file: "fabric-loader-0.14.21-1.20.1.json", exact: false},
+}
+
+func TestWriteVersionJSONRoundTrip(t *testing.T) {
+	for _, test := range roundTripTests {
+		t.Run(test.file, func(t *testing.T) {
+			in, err := ioutil.ReadFile(filepath.Join("testdata", test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ver, err := ReadVersionJSON(in)
+			if err != nil {
+				t.Fatal("ReadVersionJSON:", err)
+			}
+			out, err := WriteVersionJSON(ver)
+			if err != nil {
+				t.Fatal("WriteVersionJSON:", err)
+			}
+
+			if test.exact {
+				if string(out) != string(in) {
+					t.Errorf("output differs from input:\n%s\n%s", in, out)
+				}
+				return
+			}
+
+			var inValue, outValue interface{}
+			if err := json.Unmarshal(in, &inValue); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(out, &outValue); err != nil {
+				t.Fatal("output is not valid JSON:", err)
+			}
+			if !reflect.DeepEqual(inValue, outValue) {
+				t.Errorf("output contents differ from input:\n%s\n%s", in, out)
+			}
+		})
+	}
+}
+
+func TestWriteVersionJSONNewerLauncher(t *testing.T) {
+	in, err := ioutil.ReadFile(filepath.Join("testdata", "1.20.1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	in = bytes.Replace(in, []byte(`"minimumLauncherVersion": 21`), []byte(`"minimumLauncherVersion": 22`), 1)
+
+	ver, err := ReadVersionJSON(in)
+	if err != nil {
+		t.Fatal("ReadVersionJSON:", err)
+	}
+	if len(ver.GameArgs) == 0 || len(ver.JVMArgs) == 0 {
+		t.Error("arguments are not parsed")
+	}
+	out, err := WriteVersionJSON(ver)
+	if err != nil {
+		t.Fatal("WriteVersionJSON:", err)
+	}
+	if string(out) != string(in) {
+		t.Errorf("output differs from input:\n%s\n%s", in, out)
+	}
+}